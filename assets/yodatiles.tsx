@@ -1,4 +0,0 @@
-<?xml version="1.0" encoding="UTF-8"?>
-<tileset version="1.5" tiledversion="1.7.2" name="yodatiles" tilewidth="32" tileheight="32" tilecount="2140" columns="20">
- <image source="yodatiles.png" trans="000000" width="640" height="3424"/>
-</tileset>
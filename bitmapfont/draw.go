@@ -0,0 +1,33 @@
+package bitmapfont
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// Draw text in the given colour, with the top of its first line at y.
+// Each line gets lined up on its own: starting at x, centered on it, or ending at it.
+func (f *Font) Draw(dst *ebiten.Image, s string, x, y int, clr color.Color, align Align) {
+	f.DrawScaled(dst, s, x, y, 1, clr, align)
+}
+
+// Draw, blown up by a whole number of times
+func (f *Font) DrawScaled(dst *ebiten.Image, s string, x, y, scale int, clr color.Color, align Align) {
+	fc := f.Face(scale)
+	for i, line := range strings.Split(s, "\n") {
+		if line == "" {
+			continue
+		}
+		lx := x
+		switch align {
+		case AlignCenter:
+			lx -= f.Width(line) * fc.Scale / 2
+		case AlignRight:
+			lx -= f.Width(line) * fc.Scale
+		}
+		text.Draw(dst, line, fc, lx, y+(i*f.LineHeight+Baseline)*fc.Scale, clr)
+	}
+}
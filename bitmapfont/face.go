@@ -0,0 +1,98 @@
+package bitmapfont
+
+import (
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The font as a font.Face, blown up by a whole number of times, for ebitenui and ebiten's text package
+type Face struct {
+	Font  *Font
+	Scale int
+	masks map[byte]*image.Alpha
+}
+
+// The Face at a given scale; asking again gets the same one back
+func (f *Font) Face(scale int) *Face {
+	if scale < 1 {
+		scale = 1
+	}
+	if fc, ok := f.faces[scale]; ok {
+		return fc
+	}
+	fc := &Face{Font: f, Scale: scale, masks: make(map[byte]*image.Alpha)}
+	f.faces[scale] = fc
+	return fc
+}
+
+func (fc *Face) Close() error {
+	return nil
+}
+
+func (fc *Face) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	c := fc.Font.code(r)
+	g := fc.Font.glyph(c)
+	s := fc.Scale
+	advance := fixed.I(fc.Font.advance(c) * s)
+	if g.empty {
+		return image.Rectangle{}, image.NewAlpha(image.Rectangle{}), image.Point{}, advance, true
+	}
+
+	x, y := dot.X.Round(), dot.Y.Round()-Baseline*s
+	dr := image.Rect(x, y, x+(g.right-g.left+1)*s, y+GlyphHeight*s)
+	return dr, fc.mask(c), image.Pt(g.left*s, 0), advance, true
+}
+
+func (fc *Face) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	c := fc.Font.code(r)
+	g := fc.Font.glyph(c)
+	s := fc.Scale
+	advance := fixed.I(fc.Font.advance(c) * s)
+	if g.empty {
+		return fixed.Rectangle26_6{}, advance, true
+	}
+	return fixed.R(0, -Baseline*s, (g.right-g.left+1)*s, (GlyphHeight-Baseline)*s), advance, true
+}
+
+func (fc *Face) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return fixed.I(fc.Font.advance(fc.Font.code(r)) * fc.Scale), true
+}
+
+func (fc *Face) Kern(r0, r1 rune) fixed.Int26_6 {
+	return fixed.I(fc.Font.kern(fc.Font.code(r0), fc.Font.code(r1)) * fc.Scale)
+}
+
+func (fc *Face) Metrics() font.Metrics {
+	s := fc.Scale
+	return font.Metrics{
+		Height:     fixed.I(fc.Font.LineHeight * s),
+		Ascent:     fixed.I(Baseline * s),
+		Descent:    fixed.I((GlyphHeight - Baseline) * s),
+		XHeight:    fixed.I((Baseline - fc.Font.glyph('x').top) * s),
+		CapHeight:  fixed.I((Baseline - fc.Font.glyph('H').top) * s),
+		CaretSlope: image.Pt(0, 1),
+	}
+}
+
+// A glyph's cell, scaled up
+func (fc *Face) mask(c byte) *image.Alpha {
+	if m, ok := fc.masks[c]; ok {
+		return m
+	}
+	src := fc.Font.glyph(c).mask
+	if fc.Scale == 1 {
+		fc.masks[c] = src
+		return src
+	}
+	s := fc.Scale
+	m := image.NewAlpha(image.Rect(0, 0, GlyphWidth*s, GlyphHeight*s))
+	for y := 0; y < GlyphHeight*s; y++ {
+		for x := 0; x < GlyphWidth*s; x++ {
+			m.SetAlpha(x, y, src.AlphaAt(x/s, y/s))
+		}
+	}
+	fc.masks[c] = m
+	return m
+}
@@ -0,0 +1,287 @@
+// Package bitmapfont draws text with the 16x20 glyph sheet in assets/font_16x20.png,
+// which everything in the UI that isn't from the original game is built from.
+package bitmapfont
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"os"
+	"strings"
+)
+
+// Bitmap font:
+// - the sheet is a grid of 16x20 glyphs, in code page 437 order starting from the space
+// - each glyph is only as wide as what's drawn in it, and pairs that fit together get kerned closer
+// - measuring, word wrap and alignment, for laying text out
+// - a font.Face, so ebiten's text package and ebitenui can draw it too (see face.go)
+
+const GlyphWidth, GlyphHeight int = 16, 20
+const Baseline int = 18 // Rows above the baseline; descenders get the last 2
+
+const firstChar = 0x20 // The sheet leaves out the control characters
+const lastChar = 0xB2  // After ▓ the sheet has UI bits (frames, icons, key caps) instead of box drawing
+const maxKern = 3      // Kerning never pulls a pair closer together than this
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Code page 437 from 0x80 up to lastChar, as runes
+const cp437High = "ÇüéâäàåçêëèïîìÄÅÉæÆôöòûùÿÖÜ¢£¥₧ƒáíóúñÑªº¿⌐¬½¼¡«»░▒▓"
+
+// Anything else that's close enough to something in the sheet
+var lookalikes = map[rune]byte{
+	'⌂': 0x7F,
+	'‘': '\'',
+	'’': '\'',
+	'“': '"',
+	'”': '"',
+	'–': '-',
+	'—': '-',
+	'…': '.',
+}
+
+type glyph struct {
+	mask  *image.Alpha // The whole cell; how bright each pixel is goes in its alpha
+	empty bool
+	top   int // First row with anything in it
+	left  int // First and last columns with anything in them
+	right int
+	// For each row, the first and last columns with anything in them; -1 if it's empty
+	rowLeft  [GlyphHeight]int
+	rowRight [GlyphHeight]int
+}
+
+type Font struct {
+	Spacing    int // Pixels between glyphs
+	SpaceWidth int
+	LineHeight int
+
+	glyphs  []*glyph // By CP437 code, less firstChar
+	runes   map[rune]byte
+	kerning map[[2]byte]int
+	faces   map[int]*Face // By scale; the text package caches glyphs per Face, so they stick around
+}
+
+// Load the sheet from a PNG file
+func Load(path string) (*Font, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading font %s: %w", path, err)
+	}
+	return New(img)
+}
+
+// Slice a sheet into glyphs
+func New(sheet image.Image) (*Font, error) {
+	b := sheet.Bounds()
+	columns, rows := b.Dx()/GlyphWidth, b.Dy()/GlyphHeight
+	if b.Dx()%GlyphWidth != 0 || b.Dy()%GlyphHeight != 0 || columns*rows < lastChar+1-firstChar {
+		return nil, fmt.Errorf("font sheet is %dx%d; it should be a grid of %dx%d glyphs, at least %d of them",
+			b.Dx(), b.Dy(), GlyphWidth, GlyphHeight, lastChar+1-firstChar)
+	}
+
+	f := &Font{
+		Spacing:    1,
+		SpaceWidth: 6,
+		LineHeight: GlyphHeight,
+		glyphs:     make([]*glyph, columns*rows),
+		runes:      make(map[rune]byte),
+		kerning:    make(map[[2]byte]int),
+		faces:      make(map[int]*Face),
+	}
+	for i := range f.glyphs {
+		cx, cy := b.Min.X+(i%columns)*GlyphWidth, b.Min.Y+(i/columns)*GlyphHeight
+		f.glyphs[i] = sliceGlyph(sheet, cx, cy)
+	}
+
+	for r := rune(firstChar); r < 0x80; r++ {
+		f.runes[r] = byte(r)
+	}
+	code := 0x80
+	for _, r := range cp437High {
+		f.runes[r] = byte(code)
+		code++
+	}
+	for r, c := range lookalikes {
+		if _, ok := f.runes[r]; !ok {
+			f.runes[r] = c
+		}
+	}
+	return f, nil
+}
+
+func sliceGlyph(sheet image.Image, cx, cy int) *glyph {
+	g := &glyph{
+		mask:  image.NewAlpha(image.Rect(0, 0, GlyphWidth, GlyphHeight)),
+		empty: true,
+		left:  GlyphWidth,
+		right: -1,
+	}
+	for y := 0; y < GlyphHeight; y++ {
+		g.rowLeft[y], g.rowRight[y] = -1, -1
+		for x := 0; x < GlyphWidth; x++ {
+			lum := color.GrayModel.Convert(sheet.At(cx+x, cy+y)).(color.Gray).Y
+			if lum == 0 {
+				continue
+			}
+			g.mask.SetAlpha(x, y, color.Alpha{A: lum})
+			if g.empty {
+				g.top = y
+				g.empty = false
+			}
+			if g.rowLeft[y] < 0 {
+				g.rowLeft[y] = x
+			}
+			g.rowRight[y] = x
+			if x < g.left {
+				g.left = x
+			}
+			if x > g.right {
+				g.right = x
+			}
+		}
+	}
+	return g
+}
+
+// Which glyph to use for a rune; anything the sheet doesn't have is a ?
+func (f *Font) code(r rune) byte {
+	if c, ok := f.runes[r]; ok {
+		return c
+	}
+	return '?'
+}
+
+func (f *Font) glyph(c byte) *glyph {
+	return f.glyphs[int(c)-firstChar]
+}
+
+// How far along to move after drawing a glyph, kerning aside
+func (f *Font) advance(c byte) int {
+	g := f.glyph(c)
+	if g.empty {
+		return f.SpaceWidth
+	}
+	return g.right - g.left + 1 + f.Spacing
+}
+
+// How much closer (so, 0 or less) to put b after a, going by how far apart they are
+// at their closest, row by row. Rows next to each other count too, so diagonals don't touch.
+func (f *Font) kern(a, b byte) int {
+	pair := [2]byte{a, b}
+	if k, ok := f.kerning[pair]; ok {
+		return k
+	}
+
+	ga, gb := f.glyph(a), f.glyph(b)
+	gap := maxKern
+	if !ga.empty && !gb.empty {
+		for y := 0; y < GlyphHeight; y++ {
+			if ga.rowRight[y] < 0 {
+				continue
+			}
+			for ny := y - 1; ny <= y+1; ny++ {
+				if ny < 0 || ny >= GlyphHeight || gb.rowLeft[ny] < 0 {
+					continue
+				}
+				if g := (ga.right - ga.rowRight[y]) + (gb.rowLeft[ny] - gb.left); g < gap {
+					gap = g
+				}
+			}
+		}
+	} else {
+		gap = 0
+	}
+	f.kerning[pair] = -gap
+	return -gap
+}
+
+// How wide a line of text is, in pixels
+func (f *Font) Width(line string) int {
+	w := 0
+	prev := -1
+	for _, r := range line {
+		c := f.code(r)
+		if prev >= 0 {
+			w += f.kern(byte(prev), c)
+		}
+		w += f.advance(c)
+		prev = int(c)
+	}
+	// No gap needed after the last glyph
+	if prev >= 0 && !f.glyph(byte(prev)).empty {
+		w -= f.Spacing
+	}
+	return w
+}
+
+// How big a block of text is, in pixels: its widest line, by all its lines
+func (f *Font) Measure(text string) (int, int) {
+	lines := strings.Split(text, "\n")
+	w := 0
+	for _, line := range lines {
+		if lw := f.Width(line); lw > w {
+			w = lw
+		}
+	}
+	return w, len(lines) * f.LineHeight
+}
+
+// Break text into lines no wider than width pixels, at spaces where possible.
+// Line breaks in the text are kept.
+func (f *Font) Wrap(text string, width int) []string {
+	ret := make([]string, 0)
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			// Words too wide for a line get chopped up
+			for f.Width(word) > width {
+				if line != "" {
+					ret = append(ret, line)
+					line = ""
+				}
+				word = f.chop(word, width, &ret)
+			}
+			switch {
+			case line == "":
+				line = word
+			case f.Width(line+" "+word) <= width:
+				line += " " + word
+			default:
+				ret = append(ret, line)
+				line = word
+			}
+		}
+		ret = append(ret, line)
+	}
+	// Drop any blank lines off the end
+	for len(ret) > 1 && ret[len(ret)-1] == "" {
+		ret = ret[:len(ret)-1]
+	}
+	return ret
+}
+
+// Put as much of word as fits in width on a line of its own, and hand back the rest.
+// At least one character always goes, so it can't get stuck.
+func (f *Font) chop(word string, width int, lines *[]string) string {
+	r := []rune(word)
+	n := 1
+	for n < len(r) && f.Width(string(r[:n+1])) <= width {
+		n++
+	}
+	*lines = append(*lines, string(r[:n]))
+	return string(r[n:])
+}
@@ -0,0 +1,125 @@
+package main
+
+// Cache the parsed data file and tileset, so we don't redo all of it on every launch
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+// Bump this if the cache entry itself changes shape
+const cacheVersion = 1
+
+type cacheEntry struct {
+	CacheVersion  int
+	ParserVersion int
+	DataHash      string // SHA-256 of the data file
+	Data          *dta.File
+	Tileset       *image.NRGBA
+}
+
+// Folder under the user's cache dir, e.g. ~/.cache/goda-stories
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goda-stories"), nil
+}
+
+// One file per data file and parser version, so a changed file or a newer parser just misses
+func cachePath(dataHash string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-v%d.%d.gob", dataHash, dta.ParserVersion, cacheVersion)
+	return filepath.Join(dir, name), nil
+}
+
+func hashData(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Look for a cached copy of this data file; ok is false if there isn't a usable one
+func loadCache(dataHash string) (data *dta.File, tileset *image.NRGBA, ok bool) {
+	path, err := cachePath(dataHash)
+	if err != nil {
+		return nil, nil, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	entry := cacheEntry{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&entry); err != nil {
+		fmt.Printf("[Cache] Ignoring broken cache file %s: %v\n", path, err)
+		return nil, nil, false
+	}
+	if entry.CacheVersion != cacheVersion || entry.ParserVersion != dta.ParserVersion ||
+		entry.DataHash != dataHash || entry.Data == nil || entry.Tileset == nil {
+		return nil, nil, false
+	}
+	// gob doesn't keep empty maps
+	if entry.Data.Raw == nil {
+		entry.Data.Raw = make(map[string][]byte)
+	}
+	return entry.Data, entry.Tileset, true
+}
+
+// Save the parsed data for next time, and clear out entries for older files / parsers.
+// Failing to write the cache isn't a big deal: we'll just parse again next launch.
+func saveCache(dataHash string, data *dta.File, tileset *image.NRGBA) {
+	path, err := cachePath(dataHash)
+	if err != nil {
+		fmt.Printf("[Cache] No cache dir: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("[Cache] Couldn't create %s: %v\n", filepath.Dir(path), err)
+		return
+	}
+
+	b := &bytes.Buffer{}
+	err = gob.NewEncoder(b).Encode(cacheEntry{
+		CacheVersion:  cacheVersion,
+		ParserVersion: dta.ParserVersion,
+		DataHash:      dataHash,
+		Data:          data,
+		Tileset:       tileset,
+	})
+	if err != nil {
+		fmt.Printf("[Cache] Couldn't encode game data: %v\n", err)
+		return
+	}
+	// Write to a temp file first, so a crash never leaves half a cache behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0644); err != nil {
+		fmt.Printf("[Cache] Couldn't write %s: %v\n", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		fmt.Printf("[Cache] Couldn't write %s: %v\n", path, err)
+		os.Remove(tmp)
+		return
+	}
+	fmt.Printf("[Cache] Saved %s\n", path)
+
+	// Anything else in there is stale: a different data file, or an older parser
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) && strings.HasSuffix(e.Name(), ".gob") {
+			os.Remove(filepath.Join(filepath.Dir(path), e.Name()))
+		}
+	}
+}
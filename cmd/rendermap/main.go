@@ -0,0 +1,153 @@
+// rendermap draws a grid of zones straight from the data file to a PNG. Unlike the game
+// (and goda-stories -mapimage) it doesn't pull in ebiten, so it runs without a display.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+	"github.com/MasterShizzle/goda-stories/maprender"
+	"github.com/MasterShizzle/goda-stories/rng"
+	"github.com/MasterShizzle/goda-stories/worldgen"
+)
+
+func main() {
+	dataFile := flag.String("data", "data/YODESK.DTA", "the game's data file")
+	// Rows top to bottom, separated by "/"; "-" leaves a gap
+	zones := flag.String("zones", "94,95/93,96", "zone IDs to lay out, e.g. 1,2/3,4 for a 2x2 (default is Dagobah)")
+	planet := flag.Bool("planet", false, "generate a planet to draw, instead of using -zones")
+	// Same as the game's flags, so the same ones get the same planet
+	seed := flag.Int64("seed", 0, "with -planet, the seed to generate it from (the game prints its own); 0 picks one")
+	size := flag.String("size", "medium", "with -planet, how big it is: small, medium or large")
+	biome := flag.String("biome", "random", "with -planet, which one: desert, snow, forest or random")
+	steps := flag.Int("steps", 0, "with -planet, how many puzzles before the last one; 0 for the usual number")
+	quest := flag.Int("quest", -1, "with -planet, Id of the MainQuest puzzle to finish on; -1 picks one")
+	out := flag.String("o", "map.png", "PNG file to write")
+	borders := flag.Bool("borders", false, "outline each zone, with its ID")
+	hotspots := flag.Bool("hotspots", false, "mark the hotspots")
+	actors := flag.Bool("actors", false, "draw the creatures where they start")
+	blocked := flag.Bool("blocked", false, "tint the tiles you can't walk on")
+	flag.Parse()
+
+	f, err := os.Open(*dataFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := dta.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var layout [][]int
+	if *planet {
+		opts := worldgen.Options{
+			PuzzleSteps: *steps,
+			MainQuest:   *quest,
+		}
+		if *biome != "random" {
+			opts.Biome = *biome
+		}
+		n, ok := worldgen.Sizes[*size]
+		if !ok {
+			log.Fatalf("no world size %q: it's small, medium or large", *size)
+		}
+		opts.Width, opts.Height = n, n
+		layout, err = generateLayout(data, opts, *seed)
+	} else {
+		layout, err = parseLayout(*zones, len(data.Zones))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	a := maprender.NewArea(len(layout[0]), len(layout))
+	for y, row := range layout {
+		for x, zNum := range row {
+			if zNum >= 0 {
+				a.AddZone(&data.Zones[zNum], x, y, data.Tiles)
+			}
+		}
+	}
+
+	img := maprender.Render(a, data.Tiles, data.Creatures, maprender.Options{
+		ZoneBorders: *borders,
+		Hotspots:    *hotspots,
+		Actors:      *actors,
+		Blocked:     *blocked,
+	})
+	outFile, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := png.Encode(outFile, img); err != nil {
+		log.Fatal(err)
+	}
+	if err := outFile.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[rendermap] Saved %dx%d zones to %s\n", a.Width, a.Height, *out)
+}
+
+// Lay out a planet the way the game would, and print its puzzle chain.
+// The same seed as the game gets the same planet.
+func generateLayout(data *dta.File, opts worldgen.Options, seed int64) ([][]int, error) {
+	if seed == 0 {
+		seed = rng.NewSeed()
+	}
+	if opts.PuzzleSteps == 0 {
+		opts.PuzzleSteps = worldgen.DefaultSteps(opts.Width, opts.Height)
+	}
+	random := rng.New(seed)
+	p, err := worldgen.Generate(data.Zones, data.Puzzles, opts, &random.Worldgen)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[rendermap] Generated a %s planet from seed %d, landing at (%d,%d); goal is puzzle %d\n", p.Biome, seed, p.StartX, p.StartY, p.Chain.Goal)
+	for i, step := range p.Chain.Steps {
+		fmt.Printf("[rendermap] %d: %s\n", i+1, step.ToString())
+	}
+
+	// Planets are [x][y]
+	ret := make([][]int, p.Height)
+	for y := range ret {
+		ret[y] = make([]int, p.Width)
+		for x := range ret[y] {
+			ret[y][x] = p.Zones[x][y]
+		}
+	}
+	return ret, nil
+}
+
+// Zone IDs as [y][x], with -1 for gaps; every row has to be the same length
+func parseLayout(s string, zoneCount int) ([][]int, error) {
+	ret := make([][]int, 0)
+	for _, r := range strings.Split(s, "/") {
+		row := make([]int, 0)
+		for _, field := range strings.Split(r, ",") {
+			field = strings.TrimSpace(field)
+			if field == "-" {
+				row = append(row, -1)
+				continue
+			}
+			zNum, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("bad zone ID %q", field)
+			}
+			if zNum < 0 || zNum >= zoneCount {
+				return nil, fmt.Errorf("no zone %d: there are %d", zNum, zoneCount)
+			}
+			row = append(row, zNum)
+		}
+		if len(ret) > 0 && len(row) != len(ret[0]) {
+			return nil, fmt.Errorf("rows have different lengths: %q", s)
+		}
+		ret = append(ret, row)
+	}
+	return ret, nil
+}
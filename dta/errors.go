@@ -0,0 +1,27 @@
+package dta
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownSection = errors.New("unknown section")
+	ErrBadMarker      = errors.New("unexpected marker")
+	ErrBadValue       = errors.New("unexpected value")
+)
+
+// ParseError tells you where in the data file things went wrong
+type ParseError struct {
+	Section string // e.g. "ZONE", or the unrecognized section name
+	Offset  int64  // byte offset from the start of the file
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("dta: %s at offset 0x%x: %v", e.Section, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
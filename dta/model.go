@@ -0,0 +1,744 @@
+package dta
+
+import (
+	"fmt"
+)
+
+// Directions are part of the data model, since CHAR entries list a tile for each of them
+type CardinalDirection struct {
+	Name   string
+	DeltaX int
+	DeltaY int
+}
+
+var Up CardinalDirection = CardinalDirection{Name: "Up", DeltaX: 0, DeltaY: -1}
+var Down CardinalDirection = CardinalDirection{Name: "Down", DeltaX: 0, DeltaY: 1}
+var Left CardinalDirection = CardinalDirection{Name: "Left", DeltaX: -1, DeltaY: 0}
+var Right CardinalDirection = CardinalDirection{Name: "Right", DeltaX: 1, DeltaY: 0}
+var UpLeft CardinalDirection = CardinalDirection{Name: "UpLeft", DeltaX: -1, DeltaY: -1}
+var DownLeft CardinalDirection = CardinalDirection{Name: "DownLeft", DeltaX: -1, DeltaY: 1}
+var UpRight CardinalDirection = CardinalDirection{Name: "UpRight", DeltaX: 1, DeltaY: -1}
+var DownRight CardinalDirection = CardinalDirection{Name: "DownRight", DeltaX: 1, DeltaY: 1}
+var NoMove CardinalDirection = CardinalDirection{Name: "None", DeltaX: 0, DeltaY: 0}
+
+func (d *CardinalDirection) IsDirection() bool {
+	// Return true if movement is non-zero
+	return !(d.DeltaX == 0 && d.DeltaY == 0)
+}
+
+func (d *CardinalDirection) IsHorizontal() bool {
+	return d.DeltaX != 0
+}
+
+func (d *CardinalDirection) IsVertical() bool {
+	return d.DeltaY != 0
+}
+
+func (d *CardinalDirection) IsDiagonal() bool {
+	return d.DeltaX != 0 && d.DeltaY != 0
+}
+
+type ZoneInfo struct {
+	Id          int
+	Biome       string
+	Width       int
+	Height      int
+	Type        string
+	IsOverworld bool
+	TileMaps    struct {
+		Terrain []int
+		Walls   []int
+		Overlay []int
+	}
+	Hotspots       []ZoneHotspot
+	ActionTriggers []ActionTrigger
+	ZoneActors     []ZoneActor
+	RewardItems    []int // IZX2
+	QuestNPCs      []int // IZX3
+	Izx4a          int
+	Izx4b          string
+
+	// Stuff we don't use (yet), kept so the zone can be written back out unchanged
+	Planet        int // The 2 bytes in front of each IZON entry
+	TypeId        int // Only used when Type isn't one we know
+	BiomeId       int // Only used when Biome isn't one we know
+	IzonSize      int
+	SharedCounter int
+	IzaxUnknown   int
+}
+
+type ZoneActor struct {
+	Index      int
+	CreatureId int
+	ZoneX      int
+	ZoneY      int
+	Args       []byte
+	Unknown    []byte
+}
+
+// Tile triggers
+type TriggerConditionType byte
+type TriggerActionType byte
+type TriggerHotspotType int
+
+const (
+	FirstEnter      TriggerConditionType = 0x00
+	Enter           TriggerConditionType = 0x01
+	BumpTile        TriggerConditionType = 0x02
+	UseItem         TriggerConditionType = 0x03
+	Walk            TriggerConditionType = 0x04
+	TempVarEq       TriggerConditionType = 0x05
+	RandVarEq       TriggerConditionType = 0x06
+	RandVarGt       TriggerConditionType = 0x07
+	RandVarLt       TriggerConditionType = 0x08
+	EnterVehicle    TriggerConditionType = 0x09
+	CheckTile       TriggerConditionType = 0x0A
+	EnemyDead       TriggerConditionType = 0x0B
+	AllEnemiesDead  TriggerConditionType = 0x0C
+	HasItem         TriggerConditionType = 0x0D
+	CheckQuestItem1 TriggerConditionType = 0x0E
+	CheckQuestItem2 TriggerConditionType = 0x0F
+	Unknown10       TriggerConditionType = 0x10
+	GameInProgress  TriggerConditionType = 0x11
+	GameCompleted   TriggerConditionType = 0x12
+	HealthLt        TriggerConditionType = 0x13
+	HealthGt        TriggerConditionType = 0x14
+	Unknown15       TriggerConditionType = 0x15
+	Unknown16       TriggerConditionType = 0x16
+	UseWrongItem    TriggerConditionType = 0x17
+	PlayerAtPos     TriggerConditionType = 0x18
+	GlobalVarEq     TriggerConditionType = 0x19
+	GlobalVarLt     TriggerConditionType = 0x1A
+	GlobalVarGt     TriggerConditionType = 0x1B
+	ExperienceEq    TriggerConditionType = 0x1C
+	Unknown1D       TriggerConditionType = 0x1D
+	Unknown1E       TriggerConditionType = 0x1E
+	TempVarNe       TriggerConditionType = 0x1F
+	RandVarNe       TriggerConditionType = 0x20
+	GlobalVarNe     TriggerConditionType = 0x21
+	CheckTileVar    TriggerConditionType = 0x22
+	ExperienceGt    TriggerConditionType = 0x23
+)
+
+const (
+	SetTile         TriggerActionType = 0x00
+	ClearTile       TriggerActionType = 0x01
+	MoveTile        TriggerActionType = 0x02
+	DrawOverlayTile TriggerActionType = 0x03
+	PlayerSay       TriggerActionType = 0x04
+	CreatureSay     TriggerActionType = 0x05
+	RedrawTile      TriggerActionType = 0x06
+	RedrawRect      TriggerActionType = 0x07
+	RenderChanges   TriggerActionType = 0x08
+	WaitTicks       TriggerActionType = 0x09
+	PlaySound       TriggerActionType = 0x0a
+	FadeIn          TriggerActionType = 0x0b
+	RandomNum       TriggerActionType = 0x0c
+	SetTempVar      TriggerActionType = 0x0d
+	AddTempVar      TriggerActionType = 0x0e
+	SetTileVar      TriggerActionType = 0x0f
+	ReleaseCamera   TriggerActionType = 0x10
+	LockCamera      TriggerActionType = 0x11
+	SetPlayerPos    TriggerActionType = 0x12
+	MoveCamera      TriggerActionType = 0x13
+	RunOnlyOnce     TriggerActionType = 0x14
+	ShowObject      TriggerActionType = 0x15
+	HideObject      TriggerActionType = 0x16
+	ShowEntity      TriggerActionType = 0x17
+	HideEntity      TriggerActionType = 0x18
+	ShowAllEntities TriggerActionType = 0x19
+	HideAllEntities TriggerActionType = 0x1a
+	SpawnItem       TriggerActionType = 0x1b
+	GiveToPlayer    TriggerActionType = 0x1c
+	TakeFromPlayer  TriggerActionType = 0x1d
+	OpenOrShow      TriggerActionType = 0x1e
+	Unknown1f       TriggerActionType = 0x1f
+	Unknown20       TriggerActionType = 0x20
+	GoToZone        TriggerActionType = 0x21
+	SetGlobalVar    TriggerActionType = 0x22
+	AddGlobalVar    TriggerActionType = 0x23
+	SetRandVar      TriggerActionType = 0x24
+	AddToHealth     TriggerActionType = 0x25
+)
+
+const (
+	TriggerSpot        TriggerHotspotType = 0
+	SpawnLocation      TriggerHotspotType = 1
+	ForceLocation      TriggerHotspotType = 2
+	VehicleToSubarea   TriggerHotspotType = 3
+	VehicleToOverworld TriggerHotspotType = 4
+	LocatorSpot        TriggerHotspotType = 5
+	ItemSpot           TriggerHotspotType = 6
+	QuestNPCSpot       TriggerHotspotType = 7
+	WeaponSpot         TriggerHotspotType = 8
+	ZoneEntrance       TriggerHotspotType = 9
+	ZoneExit           TriggerHotspotType = 10
+	UNUSED             TriggerHotspotType = 11
+	LockSpot           TriggerHotspotType = 12
+	TeleportSpot       TriggerHotspotType = 13
+	XWingFromDagobah   TriggerHotspotType = 14
+	XWingToDagobah     TriggerHotspotType = 15
+	UNKNOWNHOTSPOT     TriggerHotspotType = 16
+)
+
+type TriggerCondition struct {
+	Condition TriggerConditionType
+	Args      []int
+}
+
+type TriggerAction struct {
+	Action TriggerActionType
+	Args   []int
+	Text   string
+}
+
+type ActionTrigger struct {
+	Conditions []TriggerCondition
+	Actions    []TriggerAction
+}
+
+type TileInfo struct {
+	Id         int
+	Flags      TileFlags
+	Type       TileType
+	IsWalkable bool
+	Pixels     []byte // 32x32 palette indexes, one byte per pixel
+}
+
+// TileFlags are the 32 bits in front of each tile's pixels.
+// The low 9 bits say what kind of tile it is, and bits 16 and up
+// mean different things depending on which kind that is.
+type TileFlags uint32
+
+const (
+	TileTransparent TileFlags = 1 << iota // Palette index 0 shows what's underneath
+	TileFloor
+	TileObject
+	TileDraggable
+	TileRoof
+	TileLocator
+	TileWeapon
+	TileItem
+	TileCharacter
+)
+
+// Floor subtypes
+const (
+	FloorDoorway TileFlags = 1 << (16 + iota) // Or anything else that does something when stepped on
+)
+
+// Item subtypes
+const (
+	ItemKeycard TileFlags = 1 << (16 + iota)
+	ItemTool
+	ItemPart
+	ItemValuable
+	ItemLocator
+	_
+	ItemEdible
+)
+
+// Weapon subtypes
+const (
+	WeaponLightBlaster TileFlags = 1 << (16 + iota)
+	WeaponHeavyBlaster
+	WeaponLightsaber
+	WeaponTheForce
+)
+
+// Character subtypes
+const (
+	CharacterHero TileFlags = 1 << (16 + iota)
+	CharacterEnemy
+	CharacterNPC
+)
+
+// Just the 9 bits that say what kind of tile it is
+const tileTypeMask TileFlags = 0x1FF
+
+func (f TileFlags) Has(bits TileFlags) bool {
+	return f&bits == bits
+}
+
+func (f TileFlags) Transparent() bool { return f.Has(TileTransparent) }
+func (f TileFlags) Floor() bool       { return f.Has(TileFloor) }
+func (f TileFlags) Object() bool      { return f.Has(TileObject) }
+func (f TileFlags) Draggable() bool   { return f.Has(TileDraggable) }
+func (f TileFlags) Roof() bool        { return f.Has(TileRoof) }
+func (f TileFlags) Locator() bool     { return f.Has(TileLocator) }
+func (f TileFlags) Weapon() bool      { return f.Has(TileWeapon) }
+func (f TileFlags) Item() bool        { return f.Has(TileItem) }
+func (f TileFlags) Character() bool   { return f.Has(TileCharacter) }
+
+// The subtype bits only count for the right kind of tile
+func (f TileFlags) Doorway() bool      { return f.Floor() && f.Has(FloorDoorway) }
+func (f TileFlags) Keycard() bool      { return f.Item() && f.Has(ItemKeycard) }
+func (f TileFlags) Tool() bool         { return f.Item() && f.Has(ItemTool) }
+func (f TileFlags) Part() bool         { return f.Item() && f.Has(ItemPart) }
+func (f TileFlags) Valuable() bool     { return f.Item() && f.Has(ItemValuable) }
+func (f TileFlags) LocatorItem() bool  { return f.Item() && f.Has(ItemLocator) }
+func (f TileFlags) Edible() bool       { return f.Item() && f.Has(ItemEdible) }
+func (f TileFlags) LightBlaster() bool { return f.Weapon() && f.Has(WeaponLightBlaster) }
+func (f TileFlags) HeavyBlaster() bool { return f.Weapon() && f.Has(WeaponHeavyBlaster) }
+func (f TileFlags) Lightsaber() bool   { return f.Weapon() && f.Has(WeaponLightsaber) }
+func (f TileFlags) TheForce() bool     { return f.Weapon() && f.Has(WeaponTheForce) }
+func (f TileFlags) Hero() bool         { return f.Character() && f.Has(CharacterHero) }
+func (f TileFlags) Enemy() bool        { return f.Character() && f.Has(CharacterEnemy) }
+func (f TileFlags) NPC() bool          { return f.Character() && f.Has(CharacterNPC) }
+
+// The low bits, written out like the docs do: bit 0 first
+func (f TileFlags) ToString() string {
+	ret := make([]byte, 9)
+	for i := range ret {
+		ret[i] = '0'
+		if f&(1<<i) != 0 {
+			ret[i] = '1'
+		}
+	}
+	return string(ret)
+}
+
+// The categories from docs/Extraction.md, worked out from the flags
+type TileType int
+
+const (
+	OtherTile TileType = iota
+	TerrainTile
+	ObjectTile
+	WallTile
+	BlockTile
+	OverlayTile
+	CreatureTile
+	ItemTile
+	WeaponTile
+	LocatorTile
+)
+
+func (t TileType) ToString() string {
+	switch t {
+	case OtherTile:
+		return ""
+	case TerrainTile:
+		return "Terrain"
+	case ObjectTile:
+		return "Object"
+	case WallTile:
+		return "Wall"
+	case BlockTile:
+		return "Block"
+	case OverlayTile:
+		return "Overlay"
+	case CreatureTile:
+		return "Creature"
+	case ItemTile:
+		return "Item"
+	case WeaponTile:
+		return "Weapon"
+	case LocatorTile:
+		return "Locator"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(t))
+}
+
+type ZoneHotspot struct {
+	Id      int
+	Type    TriggerHotspotType
+	X       int
+	Y       int
+	Enabled int
+	Arg     int
+}
+
+type ItemType int
+type PuzzleText string
+
+type PuzzleInfo struct {
+	Id           int
+	Type         string
+	ItemType     string
+	NeedText     string // "Hey, bring me a ___..."
+	HaveText     string // "...and in return, I'll give you a ___..."
+	DoneText     string // "...Thanks!" etc.
+	LockItemId   int
+	RewardItemId int
+	RewardFlags  string
+	Texts        []string // All of the puzzle's strings, including the empty ones
+	Unknown      []byte
+}
+
+type ItemInfo struct {
+	Id   int
+	Name string
+	MapX int
+	MapY int
+}
+
+// What sort of thing a creature is
+type CreatureType int
+
+const (
+	CreatureHero   CreatureType = 1
+	CreatureEnemy  CreatureType = 2
+	CreatureWeapon CreatureType = 4
+)
+
+// How a creature gets around, when the game is in charge of it
+type MovementType int
+
+const (
+	MoveNone      MovementType = 0
+	MoveSit       MovementType = 4
+	MoveWander    MovementType = 5
+	MovePatrol    MovementType = 6
+	MoveScaredy   MovementType = 7
+	MoveAnimation MovementType = 8
+	MoveChase     MovementType = 9
+)
+
+type CreatureInfo struct {
+	Id           int
+	Name         string
+	Type         CreatureType
+	Movement     MovementType
+	Images       map[CardinalDirection]int // Standing, and the first walking frame
+	WalkImages   map[CardinalDirection]int // The second walking frame
+	AttackImages map[CardinalDirection]int // Swinging / shooting, or for weapons, the part that sticks out
+	Unknown      []byte                    // The 6 bytes after the movement type
+	ExtraFrames  []byte                    // Anything after the 3 sets of frames
+
+	// From the CHWP and CAUX sections
+	Reference int // The weapon this creature uses, or for a weapon, the sound it makes (65535 for none)
+	Health    int
+	Damage    int
+}
+
+func (c CreatureType) ToString() string {
+	switch c {
+	case CreatureHero:
+		return "Hero"
+	case CreatureEnemy:
+		return "Enemy"
+	case CreatureWeapon:
+		return "Weapon"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(c))
+}
+
+func (m MovementType) ToString() string {
+	switch m {
+	case MoveNone:
+		return "None"
+	case MoveSit:
+		return "Sit"
+	case MoveWander:
+		return "Wander"
+	case MovePatrol:
+		return "Patrol"
+	case MoveScaredy:
+		return "Scaredy"
+	case MoveAnimation:
+		return "Animation"
+	case MoveChase:
+		return "Chase"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(m))
+}
+
+func (a *ActionTrigger) ToString() string {
+	ret := ""
+	for i, c := range a.Conditions {
+		if i == 0 {
+			ret += "When "
+		} else {
+			ret += "\n and "
+		}
+		ret += c.ToString()
+	}
+	ret += "...\n"
+	for _, a := range a.Actions {
+		ret += "   - " + a.ToString() + "\n"
+	}
+	return ret
+}
+
+// Short name for the condition, as the original editor might have shown it
+func (t TriggerConditionType) ToString() string {
+	switch t {
+	case FirstEnter:
+		return "FirstEnter"
+	case Enter:
+		return "ZoneEnter"
+	case BumpTile:
+		return "BumpTile"
+	case UseItem:
+		return "UseItem"
+	case Walk:
+		return "TileWalk"
+	case TempVarEq:
+		return "TVar_EQ"
+	case RandVarEq:
+		return "RVar_EQ"
+	case RandVarGt:
+		return "RVar_GT"
+	case RandVarLt:
+		return "RVar_LT"
+	case EnterVehicle:
+		return "EnterVehicle"
+	case CheckTile:
+		return "CheckTile"
+	case EnemyDead:
+		return "CrtrDead"
+	case AllEnemiesDead:
+		return "AllDead"
+	case HasItem:
+		return "HasItem"
+	case CheckQuestItem1:
+		return "Item1Is"
+	case CheckQuestItem2:
+		return "Item2Is"
+	case Unknown10:
+		return "Unkwn10"
+	case GameInProgress:
+		return "MainQuestOpen"
+	case GameCompleted:
+		return "MainQuestDone"
+	case HealthLt:
+		return "Life_LT"
+	case HealthGt:
+		return "Life_GT"
+	case Unknown15:
+		return "Unkwn15"
+	case Unknown16:
+		return "Unkwn16"
+	case UseWrongItem:
+		return "WrongItem"
+	case PlayerAtPos:
+		return "PlyrAtPos"
+	case GlobalVarEq:
+		return "GVar_EQ"
+	case GlobalVarLt:
+		return "GVar_LT"
+	case GlobalVarGt:
+		return "GVar_GT"
+	case ExperienceEq:
+		return "Wins_EQ"
+	case Unknown1D:
+		return "Unkwn1d"
+	case Unknown1E:
+		return "Unkwn1e"
+	case TempVarNe:
+		return "TVar_NE"
+	case RandVarNe:
+		return "RVar_NE"
+	case GlobalVarNe:
+		return "GVar_NE"
+	case CheckTileVar:
+		return "CheckTileVar"
+	case ExperienceGt:
+		return "Wins_GT"
+	}
+	return fmt.Sprintf("UNKNOWN(0x%02x)", byte(t))
+}
+
+// Look up a condition by the name ToString gives it; UNKNOWN(0xNN) names work too
+func ConditionTypeByName(name string) (TriggerConditionType, bool) {
+	for i := 0; i < 256; i++ {
+		if TriggerConditionType(i).ToString() == name {
+			return TriggerConditionType(i), true
+		}
+	}
+	return 0, false
+}
+
+func (t *TriggerCondition) ToString() string {
+	ret := t.Condition.ToString()
+	for _, arg := range t.Args {
+		ret += fmt.Sprintf(",%d", arg)
+	}
+	return ret
+}
+
+// Short name for the action, as the original editor might have shown it
+func (a TriggerActionType) ToString() string {
+	switch a {
+	case SetTile:
+		return "SetTile"
+	case ClearTile:
+		return "ClearTile"
+	case MoveTile:
+		return "MoveTile"
+	case DrawOverlayTile:
+		return "DrawOver"
+	case PlayerSay:
+		return "PlyrSez"
+	case CreatureSay:
+		return "CrtrSez"
+	case RedrawTile:
+		return "DrawTile"
+	case RedrawRect:
+		return "DrawRect"
+	case RenderChanges:
+		return "DrawAll"
+	case WaitTicks:
+		return "WaitFor"
+	case PlaySound:
+		return "PlaySound"
+	case FadeIn:
+		return "FadeIn"
+	case RandomNum:
+		return "RVarRange"
+	case SetTempVar:
+		return "SetTVar"
+	case AddTempVar:
+		return "AddTVar"
+	case SetTileVar:
+		return "SetTileVar"
+	case ReleaseCamera:
+		return "FreeCam"
+	case LockCamera:
+		return "LockCam"
+	case SetPlayerPos:
+		return "SetPlyrPos"
+	case MoveCamera:
+		return "MoveCam"
+	case RunOnlyOnce:
+		return "RunOnce"
+	case ShowObject:
+		return "ShowObj"
+	case HideObject:
+		return "HideObj"
+	case ShowEntity:
+		return "ShowCrtr"
+	case HideEntity:
+		return "HideCrtr"
+	case ShowAllEntities:
+		return "ShowAll"
+	case HideAllEntities:
+		return "HideAll"
+	case SpawnItem:
+		return "SpawnItem"
+	case GiveToPlayer:
+		return "GiveItem"
+	case TakeFromPlayer:
+		return "TakeItem"
+	case OpenOrShow:
+		return "OpenOrShow"
+	case Unknown1f:
+		return "Unkwn1f"
+	case Unknown20:
+		return "Unkwn20"
+	case GoToZone:
+		return "GoToZone"
+	case SetGlobalVar:
+		return "SetGVar"
+	case AddGlobalVar:
+		return "AddGVar"
+	case SetRandVar:
+		return "SetRVar"
+	case AddToHealth:
+		return "AddLife"
+	}
+	return fmt.Sprintf("UNKNOWN(0x%02x)", byte(a))
+}
+
+// Look up an action by the name ToString gives it; UNKNOWN(0xNN) names work too
+func ActionTypeByName(name string) (TriggerActionType, bool) {
+	for i := 0; i < 256; i++ {
+		if TriggerActionType(i).ToString() == name {
+			return TriggerActionType(i), true
+		}
+	}
+	return 0, false
+}
+
+func (a *TriggerAction) ToString() string {
+	ret := a.Action.ToString()
+	for _, arg := range a.Args {
+		ret += fmt.Sprintf(",%d", arg)
+	}
+	ret += "," + a.Text
+	return ret
+}
+
+// The constant's name, which (unlike ZoneHotspot.ToString) is different for every type
+func (t TriggerHotspotType) ToString() string {
+	switch t {
+	case TriggerSpot:
+		return "TriggerSpot"
+	case SpawnLocation:
+		return "SpawnLocation"
+	case ForceLocation:
+		return "ForceLocation"
+	case VehicleToSubarea:
+		return "VehicleToSubarea"
+	case VehicleToOverworld:
+		return "VehicleToOverworld"
+	case LocatorSpot:
+		return "LocatorSpot"
+	case ItemSpot:
+		return "ItemSpot"
+	case QuestNPCSpot:
+		return "QuestNPCSpot"
+	case WeaponSpot:
+		return "WeaponSpot"
+	case ZoneEntrance:
+		return "ZoneEntrance"
+	case ZoneExit:
+		return "ZoneExit"
+	case UNUSED:
+		return "Unused"
+	case LockSpot:
+		return "LockSpot"
+	case TeleportSpot:
+		return "TeleportSpot"
+	case XWingFromDagobah:
+		return "XWingFromDagobah"
+	case XWingToDagobah:
+		return "XWingToDagobah"
+	case UNKNOWNHOTSPOT:
+		return "Unknown"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(t))
+}
+
+func (hs *ZoneHotspot) ToString() string {
+	// ret := fmt.Sprintf("%02d (%d, %d) ", hs.Id, hs.X, hs.Y)
+	ret := ""
+	switch hs.Type {
+	case ZoneEntrance:
+		ret += "EnterZone"
+	case ZoneExit:
+		ret += "ExitZone"
+	case VehicleToSubarea, VehicleToOverworld:
+		ret += "VehicleSpot"
+	case XWingToDagobah, XWingFromDagobah:
+		ret += "XwingSpot"
+	case TriggerSpot:
+		ret += "TriggerSpot"
+	case SpawnLocation:
+		ret += "NpcSpawnSpot"
+	case ForceLocation:
+		ret += "ForceSpot"
+	case LocatorSpot:
+		ret += "GetLocator"
+	case ItemSpot:
+		ret += "ItemSpot"
+	case QuestNPCSpot:
+		ret += "QuestNPC"
+	case WeaponSpot:
+		ret += "WeaponSpot"
+	case LockSpot:
+		ret += "LockSpot"
+	case TeleportSpot:
+		ret += "TPortSpot"
+	case UNUSED:
+		ret += "UNUSED"
+	case UNKNOWNHOTSPOT:
+		ret += "UNKNOWN"
+	default:
+		ret += fmt.Sprintf("UNKNOWN(%d)", int(hs.Type))
+	}
+	ret += fmt.Sprintf(",%d", hs.Arg)
+
+	return ret
+}
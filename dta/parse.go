@@ -0,0 +1,811 @@
+// Package dta reads the game's data file (YODESK.DTA, or Indy's DESKTOP.DAW) into the data model used by the engine.
+// It only needs an io.Reader, so it can be used without a window, a filesystem or ebiten.
+package dta
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Which game a data file belongs to
+type Game int
+
+const (
+	Yoda Game = iota // Yoda Stories: YODESK.DTA
+	Indy             // Indiana Jones and his Desktop Adventures: DESKTOP.DAW
+)
+
+func (g Game) ToString() string {
+	if g == Indy {
+		return "Indiana Jones and his Desktop Adventures"
+	}
+	return "Yoda Stories"
+}
+
+// Bump this whenever a change to the parser changes what ends up in a File,
+// so anything cached from an older version gets thrown out
+const ParserVersion = 1
+
+// File holds everything parsed out of a data file
+type File struct {
+	Game      Game
+	Version   uint32
+	Startup   []byte // STUP: palette indexes of the startup screen
+	Tiles     []TileInfo
+	Zones     []ZoneInfo
+	Puzzles   []PuzzleInfo
+	Items     []ItemInfo
+	Creatures []CreatureInfo
+	Sounds    []string
+
+	Sections []string          // In the order they were read, so they can be written back the same way
+	Raw      map[string][]byte // Sections we don't decode yet
+}
+
+// The startup screen is 288x288 px, one byte per pixel
+const StartupWidth, StartupHeight int = 288, 288
+
+// Decode the STUP section into the startup splash image
+func (f *File) StartupImage() *image.NRGBA {
+	return PaletteImage(f.Startup, StartupWidth, StartupHeight)
+}
+
+// Major and minor version, as listed in the VERS section
+func (f *File) VersionString() string {
+	return fmt.Sprintf("%d.%d", (f.Version>>8)&0xFF, (f.Version>>24)&0xFF)
+}
+
+// Parse reads and processes every section of a data file
+func Parse(r io.Reader) (*File, error) {
+	f := &File{Raw: make(map[string][]byte)}
+	p := &parser{r: r}
+
+	for {
+		// Grab section header
+		start := p.offset
+		header := make([]byte, 4)
+		n, err := io.ReadFull(r, header)
+		p.offset += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &ParseError{Section: "header", Offset: start, Err: err}
+		}
+
+		s := string(header)
+		f.Sections = append(f.Sections, s)
+		var c *chunk
+		switch s {
+		case "VERS":
+			if c, err = p.read(s, 4); err != nil {
+				return nil, err
+			}
+			f.Version = c.uint32()
+			// Indy is 1.0, Yoda is 2.0
+			// The ZONE layout gets the final say, though
+			if f.Version == 0x100 {
+				f.Game = Indy
+			}
+		case "STUP":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Startup = c.data
+		case "CHWP":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			parseCreatureWeapons(c, f.Creatures)
+		case "CAUX":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			parseCreatureDamage(c, f.Creatures)
+		case "ZONE":
+			if f.Zones, err = p.readZones(f); err != nil {
+				return nil, err
+			}
+		case "ZAUX", "ZAX2", "ZAX3", "ZAX4", "HTSP", "ACTN":
+			// Indy keeps the rest of the zone data in its own sections, after ZONE
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Game = Indy
+			parseZoneExtras(c, f.Zones)
+		case "TILE":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Tiles = parseTiles(c, f.Game)
+		case "PUZ2":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Puzzles = parsePuzzles(c, len(f.Tiles), f.Game)
+		case "TNAM":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Items = parseItems(c)
+		case "CHAR":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Creatures = parseCreatures(c, f.Game)
+		case "SNDS":
+			if c, err = p.readSection(s); err != nil {
+				return nil, err
+			}
+			f.Sounds = parseSounds(c)
+		case "ENDF":
+			// Whatever odd bytes are left don't matter, but keep them anyway
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				return nil, &ParseError{Section: s, Offset: p.offset, Err: err}
+			}
+			f.Raw[s] = rest
+			return f, nil
+		default:
+			return nil, &ParseError{Section: s, Offset: start, Err: ErrUnknownSection}
+		}
+
+		if c != nil && c.err != nil {
+			return nil, c.err
+		}
+	}
+
+	return f, nil
+}
+
+// Tiles are 32x32 px, one byte per pixel
+const TilePixels = 0x400
+
+func parseTiles(c *chunk, game Game) []TileInfo {
+	// Each tile has 4 bytes for the tile flags, plus the pixels
+	numTiles := len(c.data) / (4 + TilePixels)
+	ret := make([]TileInfo, numTiles)
+	for i := 0; i < numTiles; i++ {
+		ret[i] = newTileInfo(i, c.uint32(), game)
+		ret[i].Pixels = c.bytes(TilePixels)
+	}
+	return ret
+}
+
+func newTileInfo(tileId int, flags uint32, game Game) TileInfo {
+	t := TileInfo{}
+	t.Id = tileId
+	t.Flags = TileFlags(flags)
+
+	// The first 9 bits let us break down what kind of tile this is
+	// For now, this just affects collisions
+	switch t.Flags & tileTypeMask {
+	case TileFloor:
+		t.Type = TerrainTile
+		t.IsWalkable = true
+	case TileTransparent | TileObject:
+		t.Type = ObjectTile
+		t.IsWalkable = false
+	case TileObject:
+		t.Type = WallTile
+		t.IsWalkable = false
+	case TileTransparent | TileObject | TileDraggable:
+		t.Type = BlockTile
+		t.IsWalkable = false
+	case TileTransparent | TileRoof, TileRoof:
+		t.Type = OverlayTile
+		t.IsWalkable = true
+	case TileTransparent | TileCharacter:
+		t.Type = CreatureTile
+		t.IsWalkable = false
+	case TileTransparent | TileItem:
+		t.Type = ItemTile
+		t.IsWalkable = false
+	case TileTransparent | TileWeapon:
+		t.Type = WeaponTile
+		t.IsWalkable = false
+	default:
+		t.IsWalkable = true
+	}
+	// Yoda's locator minimap tiles are #817-837, though only some of them have the Locator bit;
+	// Indy's tiles are numbered differently, so there it's just the bit
+	if t.Flags.Locator() || (game == Yoda && tileId >= 817 && tileId <= 837) {
+		t.Type = LocatorTile
+	}
+
+	return t
+}
+
+// The ZONE section is a count, then that many IZON entries
+func (p *parser) readZones(f *File) ([]ZoneInfo, error) {
+	c, err := p.read("ZONE", 2)
+	if err != nil {
+		return nil, err
+	}
+	zoneCount := c.uint16()
+	ret := make([]ZoneInfo, 0, zoneCount)
+	for i := 0; i < zoneCount; i++ {
+		// Yoda has the planet (again?) and the length of the zone data up front,
+		// but Indy goes straight into the zone ID and IZON header
+		if c, err = p.read("ZONE", 6); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			if string(c.data[2:]) == "IZON" {
+				f.Game = Indy
+			} else {
+				f.Game = Yoda
+			}
+		}
+
+		planet := 0
+		var zc *chunk
+		if f.Game == Yoda {
+			planet = c.uint16()
+			zoneLength := c.uint32()
+			if zc, err = p.read("ZONE", int(zoneLength)); err != nil {
+				return nil, err
+			}
+		} else {
+			// The IZON length counts its own marker and length, so grab the length
+			// and read the rest, then stick it all back together
+			head := c.data
+			if c, err = p.read("ZONE", 4); err != nil {
+				return nil, err
+			}
+			izonSize := int(c.uint32())
+			if izonSize < 8 {
+				return nil, &ParseError{Section: "ZONE", Offset: c.base, Err: fmt.Errorf("%w: IZON length %d", ErrBadValue, izonSize)}
+			}
+			rest, err := p.read("ZONE", izonSize-8)
+			if err != nil {
+				return nil, err
+			}
+			zc = newChunk("ZONE", rest.base-10, append(append(head, c.data...), rest.data...))
+		}
+
+		z := parseZone(zc, f.Game)
+		if zc.err != nil {
+			return nil, zc.err
+		}
+		z.Planet = planet
+		ret = append(ret, z)
+	}
+	return ret, nil
+}
+
+func parseZone(c *chunk, game Game) ZoneInfo {
+	z := ZoneInfo{}
+
+	// Populate a ZoneInfo for this map
+	z.Id = c.uint16()
+	c.marker("IZON")
+	z.IzonSize = int(c.uint32())
+	z.Width = c.uint16()
+	z.Height = c.uint16()
+	z.TypeId = int(c.uint32())
+	z.Type, z.IsOverworld = zoneType(game, z.TypeId)
+	if game == Yoda {
+		z.SharedCounter = c.uint16() // Always 0xFFFF
+		z.BiomeId = c.uint16()
+		z.Biome = zoneBiome(z.BiomeId)
+	}
+
+	// Each cell has 3x two-byte ints, for 3 tiles / cell
+	z.TileMaps.Terrain = make([]int, z.Width*z.Height)
+	z.TileMaps.Walls = make([]int, z.Width*z.Height)
+	z.TileMaps.Overlay = make([]int, z.Width*z.Height)
+	for j := 0; j < (z.Width * z.Height); j++ {
+		z.TileMaps.Terrain[j] = c.uint16()
+		z.TileMaps.Walls[j] = c.uint16()
+		z.TileMaps.Overlay[j] = c.uint16()
+	}
+
+	if game == Indy {
+		// Everything else comes later, in ZAUX, HTSP, etc.
+		z.Hotspots = make([]ZoneHotspot, 0)
+		z.ActionTriggers = make([]ActionTrigger, 0)
+		return z
+	}
+
+	z.Hotspots = parseHotspots(c)
+
+	izax := auxSection(c, "IZAX")
+	parseZoneActors(izax, &z)
+	c.adopt(izax)
+
+	izx2 := auxSection(c, "IZX2")
+	z.RewardItems = parseZoneIds(izx2)
+	c.adopt(izx2)
+
+	izx3 := auxSection(c, "IZX3")
+	z.QuestNPCs = parseZoneIds(izx3)
+	c.adopt(izx3)
+
+	izx4 := auxSection(c, "IZX4")
+	parseIzx4(izx4, &z)
+	c.adopt(izx4)
+
+	// Parse actions, if there are any
+	z.ActionTriggers = make([]ActionTrigger, 0)
+	for c.err == nil && c.remaining() >= 4 {
+		z.ActionTriggers = append(z.ActionTriggers, parseIact(c))
+	}
+
+	return z
+}
+
+// Hotspots: a count, then 12 bytes each
+func parseHotspots(c *chunk) []ZoneHotspot {
+	numHotspots := c.uint16()
+	ret := make([]ZoneHotspot, numHotspots)
+	for k := 0; k < numHotspots; k++ {
+		ret[k].Type = TriggerHotspotType(c.uint32())
+		ret[k].Id = k
+		ret[k].X = c.uint16()
+		ret[k].Y = c.uint16()
+		ret[k].Enabled = c.uint16()
+		ret[k].Arg = c.uint16()
+	}
+	return ret
+}
+
+// IZAX: Zone Actors (e.g. enemy creatures wandering around the map when it loads)
+func parseZoneActors(c *chunk, z *ZoneInfo) {
+	// 4B header, 4B section length (header included)
+	//   2B Unknown, and 2B to count X 44B commands afterward
+	//   X * 44B Actors
+	//     2B Creature ID
+	//     4B X and Y coord on the map where it spawns
+	//     6B Args
+	//     ...and the rest is usually just FF? What are the rest of these bytes for?
+	z.IzaxUnknown = c.uint16()
+	numItems := c.uint16()
+	z.ZoneActors = make([]ZoneActor, numItems)
+	for i := 0; i < numItems; i++ {
+		zax := ZoneActor{
+			Index:      i,
+			CreatureId: c.uint16(),
+			ZoneX:      c.uint16(),
+			ZoneY:      c.uint16(),
+			Args:       c.bytes(6),
+		}
+		unknown := c.bytes(32)
+		chk := 0
+		for _, x := range unknown {
+			chk += int(x)
+		}
+		if chk != 8160 { // 32 * 0xFF is all 'empties'
+			zax.Unknown = unknown
+		}
+
+		z.ZoneActors[i] = zax
+	}
+}
+
+// IZX2 (item rewards) and IZX3 (quest-related NPCs)
+func parseZoneIds(c *chunk) []int {
+	// 8B Header + section length
+	// 2B Number of items
+	//   2B Item ID
+	numItems := c.uint16()
+	ret := make([]int, numItems)
+	for i := 0; i < numItems; i++ {
+		ret[i] = c.uint16()
+	}
+	return ret
+}
+
+// IZX4: always 12 bytes, header included
+func parseIzx4(c *chunk, z *ZoneInfo) {
+	z.Izx4a = c.uint16()
+	zFlags := reverse(fmt.Sprintf("%016b", c.uint16()))
+	rep := strings.NewReplacer("1", "Y", "0", ".")
+	z.Izx4b = rep.Replace(zFlags)
+}
+
+// IACT: a single action trigger, with its own length
+func parseIact(c *chunk) ActionTrigger {
+	c.marker("IACT")
+	act := c.sub(int(c.uint32()))
+	trg := parseTrigger(act)
+	c.adopt(act)
+	return trg
+}
+
+// Indy's zone sections, which hold what Yoda keeps inside each zone
+func parseZoneExtras(c *chunk, zones []ZoneInfo) {
+	switch c.section {
+	case "ZAUX", "ZAX2", "ZAX3", "ZAX4":
+		// One entry per zone, in order
+		for i := range zones {
+			switch c.section {
+			case "ZAUX":
+				izax := auxSection(c, "IZAX")
+				parseZoneActors(izax, &zones[i])
+				c.adopt(izax)
+			case "ZAX2":
+				izx2 := auxSection(c, "IZX2")
+				zones[i].RewardItems = parseZoneIds(izx2)
+				c.adopt(izx2)
+			case "ZAX3":
+				izx3 := auxSection(c, "IZX3")
+				zones[i].QuestNPCs = parseZoneIds(izx3)
+				c.adopt(izx3)
+			case "ZAX4":
+				izx4 := auxSection(c, "IZX4")
+				parseIzx4(izx4, &zones[i])
+				c.adopt(izx4)
+			}
+		}
+	case "HTSP", "ACTN":
+		// A zone ID before each zone's entries, until 0xFFFF
+		for c.err == nil && c.remaining() > 0 {
+			id := c.uint16()
+			if id == 65535 {
+				break
+			}
+			z := zoneById(c, zones, id)
+			if c.section == "HTSP" {
+				z.Hotspots = parseHotspots(c)
+				continue
+			}
+			numActions := c.uint16()
+			for i := 0; i < numActions && c.err == nil; i++ {
+				z.ActionTriggers = append(z.ActionTriggers, parseIact(c))
+			}
+		}
+	}
+}
+
+func zoneById(c *chunk, zones []ZoneInfo, id int) *ZoneInfo {
+	for i := range zones {
+		if zones[i].Id == id {
+			return &zones[i]
+		}
+	}
+	c.failf("%w: no zone %d (is the ZONE section missing?)", ErrBadValue, id)
+	return &ZoneInfo{}
+}
+
+// The IZAX, IZX2 and IZX3 sections count their own header in their length
+func auxSection(c *chunk, name string) *chunk {
+	c.marker(name)
+	sectionLength := int(c.uint32())
+	if c.err == nil && sectionLength < 8 {
+		c.failf("%w: %s length %d", ErrBadValue, name, sectionLength)
+	}
+	return c.sub(sectionLength - 8)
+}
+
+func zoneType(game Game, zt int) (string, bool) {
+	if game == Indy {
+		// Indy numbers its zone types differently, and we haven't mapped them yet
+		// Hang onto the TypeId instead of guessing
+		return "", false
+	}
+	switch zt {
+	case 1:
+		// TODO: pick the Teleporter maps out of here
+		return "Plain", true
+	case 2:
+		return "GateToNorth", true
+	case 3:
+		return "GateToSouth", true
+	case 4:
+		return "GateToEast", true
+	case 5:
+		return "GateToWest", true
+	case 6:
+		return "PortalEnter", true
+	case 7:
+		return "PortalExit", true
+	case 8:
+		return "Interior", false
+	case 9:
+		return "OpeningSplash", false
+	case 10:
+		return "FinalDestination", true
+	case 11:
+		return "HomeBase", true
+	case 13:
+		return "WinSplash", false
+	case 14:
+		return "LoseSplash", false
+	case 15:
+		return "ItemForTool", true
+	case 16:
+		return "ItemForItem", true
+	case 17:
+		return "ItemForTask", true
+	case 18:
+		return "FindTheForce", true
+	}
+	return "", false
+}
+
+func zoneBiome(p int) string {
+	switch p {
+	case 1:
+		return "desert"
+	case 2:
+		return "snow"
+	case 3:
+		return "forest"
+	case 5:
+		return "swamp"
+	}
+	return "UNKNOWN"
+}
+
+func parseTrigger(c *chunk) ActionTrigger {
+	trg := ActionTrigger{}
+
+	// Each condition is 14B
+	numConditions := c.uint16()
+	trg.Conditions = make([]TriggerCondition, numConditions)
+	for x := 0; x < numConditions; x++ {
+		con := TriggerCondition{
+			Condition: TriggerConditionType(c.uint16()),
+		}
+		con.Args = make([]int, 6)
+		for y := 0; y < 6; y++ {
+			con.Args[y] = c.uint16()
+		}
+		trg.Conditions[x] = con
+	}
+
+	// Each action is 14B, plus however long its text is
+	numActions := c.uint16()
+	trg.Actions = make([]TriggerAction, numActions)
+	for x := 0; x < numActions; x++ {
+		actn := TriggerAction{
+			Action: TriggerActionType(c.uint16()),
+		}
+		actn.Args = make([]int, 5)
+		for y := 0; y < 5; y++ {
+			actn.Args[y] = c.uint16()
+		}
+		strLen := c.uint16()
+		actn.Text = string(c.bytes(strLen))
+		trg.Actions[x] = actn
+	}
+
+	return trg
+}
+
+func parsePuzzles(c *chunk, numTiles int, game Game) []PuzzleInfo {
+	ret := make([]PuzzleInfo, 0)
+	for c.err == nil && c.remaining() > 0 {
+		// 2 bytes of puzzle ID, plus 4 for the IPUZ header
+		p := PuzzleInfo{}
+		p.Id = c.uint16()
+		if p.Id == 65535 { // End of puzzle section: we're out!
+			break
+		}
+		c.marker("IPUZ")
+		pc := c.sub(int(c.uint32()))
+
+		if game == Indy {
+			// No types, and just the one item at the end
+			p.Unknown = pc.bytes(2)
+			tc := pc.sub(pc.remaining() - 2)
+			p.Texts = puzzleStrings(tc)
+			p.NeedText, p.DoneText, p.HaveText = puzzleText(p.Texts)
+			pc.adopt(tc)
+			p.LockItemId = pc.uint16()
+			c.adopt(pc)
+
+			ret = append(ret, p)
+			continue
+		}
+
+		switch pc.uint32() {
+		case 0x00:
+			p.Type = "ItemForItem"
+		case 0x01:
+			p.Type = "ItemForTask"
+		case 0x02:
+			p.Type = "ItemForTask2"
+		case 0x03:
+			p.Type = "MainQuest"
+		}
+
+		switch itemType := pc.uint32(); itemType {
+		case 0x00:
+			p.ItemType = "Keycard"
+		case 0x01:
+			p.ItemType = "Tool"
+		case 0x02:
+			p.ItemType = "Part"
+		case 0x04:
+			p.ItemType = "PlotItem"
+		default:
+			p.ItemType = "UNKNOWN"
+			pc.failf("%w: unknown puzzle item type %d", ErrBadValue, itemType)
+		}
+		p.Unknown = pc.bytes(6)
+
+		// The text strings fill up everything but the last 4 bytes
+		// TODO: interpret 0x20 as a "newline" for dialogs?
+		tc := pc.sub(pc.remaining() - 4)
+		p.Texts = puzzleStrings(tc)
+		p.NeedText, p.DoneText, p.HaveText = puzzleText(p.Texts)
+		if p.NeedText == "" && p.DoneText == "" && p.HaveText == "" {
+			tc.failf("%w: puzzle %d has no text", ErrBadValue, p.Id)
+		}
+		pc.adopt(tc)
+
+		// 2 bytes for the puzzle Item: either this is required to complete the thing,
+		// or it's given as a reward for a different thing?
+		// Might rename these, later
+		p.LockItemId = pc.uint16()
+		reward := pc.uint16()
+		if reward > 0 && reward < numTiles {
+			p.RewardItemId = reward
+		} else { // if it's not referencing a tile, then it's probably bitflags...?
+			p.RewardItemId = 0
+			p.RewardFlags = reverse(fmt.Sprintf("%016b", reward))
+		}
+		c.adopt(pc)
+
+		ret = append(ret, p)
+	}
+
+	return ret
+}
+
+// Puzzle text is a run of 2-byte length-denoted strings, some of which are empty
+func puzzleStrings(c *chunk) []string {
+	ret := make([]string, 0)
+	for c.err == nil && c.remaining() > 0 {
+		ret = append(ret, string(c.bytes(c.uint16())))
+	}
+	return ret
+}
+
+// Where the non-empty strings start and end
+func puzzleTextSpan(texts []string) (first, last int) {
+	first, last = 0, len(texts)
+	for first < last && texts[first] == "" {
+		first++
+	}
+	for last > first && texts[last-1] == "" {
+		last--
+	}
+	return
+}
+
+// Depending on how many strings there are, they mean different things
+func puzzleText(texts []string) (need, done, have string) {
+	first, last := puzzleTextSpan(texts)
+	ret := texts[first:last]
+	switch len(ret) {
+	case 3:
+		need, done, have = ret[0], ret[1], ret[2]
+	case 2:
+		done, have = ret[0], ret[1]
+	case 1:
+		have = ret[0]
+	}
+
+	return need, done, have
+}
+
+func parseItems(c *chunk) []ItemInfo {
+	ret := make([]ItemInfo, 0)
+	// Each item entry is 26 bytes long
+	for c.err == nil && c.remaining() > 0 {
+		iInfo := ItemInfo{}
+		iInfo.Id = c.uint16()
+		if iInfo.Id == 65535 { // End of items section: we're out!
+			break
+		}
+		// Trim the zeros from the end of the "line"
+		iInfo.Name = strings.TrimRight(string(c.bytes(24)), "\x00")
+
+		ret = append(ret, iInfo)
+	}
+	return ret
+}
+
+func parseCreatures(c *chunk, game Game) []CreatureInfo {
+	ret := make([]CreatureInfo, 0)
+	// Each creature entry is 84 bytes long, header included (78 for Indy)
+	for c.err == nil && c.remaining() > 0 {
+		cInfo := CreatureInfo{}
+		cInfo.Id = c.uint16()
+		if cInfo.Id == 65535 { // End of creatures section
+			break
+		}
+		c.marker("ICHA")
+		cc := c.sub(int(c.uint32()))
+
+		// Name ends at the first 0
+		cName := cc.bytes(16)
+		if i := strings.IndexByte(string(cName), 0); i >= 0 {
+			cName = cName[:i]
+		}
+		cInfo.Name = string(cName)
+		cInfo.Type = CreatureType(cc.uint16())
+		cInfo.Movement = MovementType(cc.uint16())
+		if game == Yoda {
+			cInfo.Unknown = cc.bytes(6)
+		}
+
+		cInfo.Images = parseCreatureFrames(cc)
+		cInfo.WalkImages = parseCreatureFrames(cc)
+		cInfo.AttackImages = parseCreatureFrames(cc)
+		cInfo.ExtraFrames = cc.bytes(cc.remaining())
+		c.adopt(cc)
+
+		ret = append(ret, cInfo)
+	}
+	return ret
+}
+
+// Each set of frames is 8 tile IDs, one per direction
+var creatureFrameOrder = []CardinalDirection{UpLeft, DownRight, Up, Left, DownLeft, UpRight, Right, Down}
+
+func parseCreatureFrames(c *chunk) map[CardinalDirection]int {
+	img := make(map[CardinalDirection]int)
+	for _, dir := range creatureFrameOrder {
+		img[dir] = c.uint16()
+	}
+	return img
+}
+
+// Find a creature by ID, for the sections that add more info about them
+func creatureById(c *chunk, creatures []CreatureInfo, id int) *CreatureInfo {
+	for i := range creatures {
+		if creatures[i].Id == id {
+			return &creatures[i]
+		}
+	}
+	c.failf("%w: no creature %d (is the CHAR section missing?)", ErrBadValue, id)
+	return &CreatureInfo{}
+}
+
+// CHWP: 6 bytes for each creature, with its weapon (or sound) and health
+func parseCreatureWeapons(c *chunk, creatures []CreatureInfo) {
+	for c.err == nil && c.remaining() > 0 {
+		id := c.uint16()
+		if id == 65535 {
+			break
+		}
+		cInfo := creatureById(c, creatures, id)
+		cInfo.Reference = c.uint16()
+		cInfo.Health = c.uint16()
+	}
+}
+
+// CAUX: 4 bytes for each creature, with how much damage it does
+func parseCreatureDamage(c *chunk, creatures []CreatureInfo) {
+	for c.err == nil && c.remaining() > 0 {
+		id := c.uint16()
+		if id == 65535 {
+			break
+		}
+		creatureById(c, creatures, id).Damage = int(int16(c.uint16()))
+	}
+}
+
+func parseSounds(c *chunk) []string {
+	ret := make([]string, 0)
+	_ = c.uint16() // Number of sounds
+	for c.err == nil && c.remaining() > 0 {
+		// Each name is null-terminated, and the length includes the terminator
+		ret = append(ret, strings.TrimRight(string(c.bytes(c.uint16())), "\x00"))
+	}
+
+	return ret
+}
+
+func reverse(str string) (result string) {
+	// Given a string, return it in reverse order
+	for _, v := range str {
+		result = string(v) + result
+	}
+	return
+}
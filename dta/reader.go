@@ -0,0 +1,137 @@
+package dta
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// A chunk walks through a block of bytes from the data file, and keeps track
+// of where it is so that errors can point to the right offset.
+// Once something goes wrong, the first error sticks and every read after it returns zeros.
+type chunk struct {
+	section string
+	base    int64 // File offset of data[0]
+	data    []byte
+	pos     int
+	err     error
+}
+
+func newChunk(section string, base int64, data []byte) *chunk {
+	return &chunk{
+		section: section,
+		base:    base,
+		data:    data,
+	}
+}
+
+func (c *chunk) offset() int64 {
+	return c.base + int64(c.pos)
+}
+
+func (c *chunk) remaining() int {
+	return len(c.data) - c.pos
+}
+
+func (c *chunk) fail(err error) {
+	if c.err == nil {
+		c.err = &ParseError{Section: c.section, Offset: c.offset(), Err: err}
+	}
+}
+
+func (c *chunk) failf(format string, args ...interface{}) {
+	c.fail(fmt.Errorf(format, args...))
+}
+
+func (c *chunk) bytes(n int) []byte {
+	if c.err != nil {
+		return nil
+	}
+	if n < 0 || n > c.remaining() {
+		c.fail(io.ErrUnexpectedEOF)
+		return nil
+	}
+	b := c.data[c.pos : c.pos+n]
+	c.pos += n
+	return b
+}
+
+func (c *chunk) uint8() int {
+	b := c.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return int(b[0])
+}
+
+func (c *chunk) uint16() int {
+	b := c.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(b))
+}
+
+func (c *chunk) uint32() uint32 {
+	b := c.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// Read a 4-byte marker like "IZON", and complain if it's not the one we wanted
+func (c *chunk) marker(want string) {
+	start := c.pos
+	b := c.bytes(4)
+	if c.err == nil && string(b) != want {
+		c.pos = start
+		c.failf("%w: want %q, got %q", ErrBadMarker, want, b)
+	}
+}
+
+// Carve the next n bytes off into their own chunk
+func (c *chunk) sub(n int) *chunk {
+	base := c.offset()
+	b := c.bytes(n)
+	if b == nil {
+		return &chunk{section: c.section, base: base, err: c.err}
+	}
+	return newChunk(c.section, base, b)
+}
+
+// The parser reads whole sections out of the data file, one after another
+type parser struct {
+	r      io.Reader
+	offset int64
+}
+
+func (p *parser) read(section string, n int) (*chunk, error) {
+	base := p.offset
+	b := make([]byte, n)
+	got, err := io.ReadFull(p.r, b)
+	p.offset += int64(got)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &ParseError{Section: section, Offset: p.offset, Err: err}
+	}
+	return newChunk(section, base, b), nil
+}
+
+// Most sections are a 4-byte length, followed by that many bytes
+func (p *parser) readSection(section string) (*chunk, error) {
+	c, err := p.read(section, 4)
+	if err != nil {
+		return nil, err
+	}
+	return p.read(section, int(c.uint32()))
+}
+
+// Pass along any error from a chunk that was carved off this one
+func (c *chunk) adopt(s *chunk) {
+	if c.err == nil {
+		c.err = s.err
+	}
+}
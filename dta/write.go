@@ -0,0 +1,518 @@
+package dta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The order sections appear in YODESK.DTA, for files that didn't come from Parse
+var DefaultSections = []string{"VERS", "STUP", "SNDS", "TILE", "ZONE", "PUZ2", "CHAR", "CHWP", "CAUX", "TNAM", "ENDF"}
+
+// Same, for DESKTOP.DAW, with the zone sections split out
+var DefaultIndySections = []string{"VERS", "STUP", "SNDS", "TILE", "ZONE", "ZAUX", "ZAX2", "ZAX3", "ZAX4", "HTSP", "ACTN", "PUZ2", "CHAR", "CHWP", "CAUX", "TNAM", "ENDF"}
+
+// A builder puts together the bytes for a section, in the same layout the parser reads them
+type builder struct {
+	bytes.Buffer
+}
+
+func (b *builder) uint16(v int) {
+	binary.Write(b, binary.LittleEndian, uint16(v))
+}
+
+func (b *builder) uint32(v uint32) {
+	binary.Write(b, binary.LittleEndian, v)
+}
+
+// A length-denoted block: 4B marker, 4B length, then the contents
+func (b *builder) section(marker string, contents []byte) {
+	b.WriteString(marker)
+	b.uint32(uint32(len(contents)))
+	b.Write(contents)
+}
+
+// Same, but the length includes the 8 bytes of header
+func (b *builder) auxSection(marker string, contents []byte) {
+	b.WriteString(marker)
+	b.uint32(uint32(len(contents) + 8))
+	b.Write(contents)
+}
+
+// Write out a string into a fixed-size, zero-padded field
+func (b *builder) fixedString(s string, size int) error {
+	if len(s) > size {
+		return fmt.Errorf("%q is too long for a %d byte field", s, size)
+	}
+	b.WriteString(s)
+	b.Write(make([]byte, size-len(s)))
+	return nil
+}
+
+// Write serializes f into the data file format.
+// Parsing a file and writing it back out, without changing anything, gives the same bytes.
+func Write(w io.Writer, f *File) error {
+	sections := f.Sections
+	if len(sections) == 0 && f.Game == Indy {
+		sections = DefaultIndySections
+	} else if len(sections) == 0 {
+		sections = DefaultSections
+	}
+
+	out := &builder{}
+	for _, s := range sections {
+		var err error
+		var data []byte
+		switch s {
+		case "VERS":
+			out.WriteString(s)
+			out.uint32(f.Version)
+			continue
+		case "ZONE":
+			// Zones have their own count + lengths, instead of a section length
+			out.WriteString(s)
+			err = writeZones(out, f.Zones, f.Game)
+		case "ENDF":
+			out.WriteString(s)
+			out.Write(f.Raw[s])
+			continue
+		case "ZAUX", "ZAX2", "ZAX3", "ZAX4", "HTSP", "ACTN":
+			data, err = writeZoneExtras(s, f.Zones)
+		case "STUP":
+			data = f.Startup
+		case "SNDS":
+			data = writeSounds(f.Sounds)
+		case "TILE":
+			data, err = writeTiles(f.Tiles)
+		case "PUZ2":
+			data, err = writePuzzles(f.Puzzles, f.Game)
+		case "CHAR":
+			data, err = writeCreatures(f.Creatures, f.Game)
+		case "CHWP":
+			data = writeCreatureWeapons(f.Creatures)
+		case "CAUX":
+			data = writeCreatureDamage(f.Creatures)
+		case "TNAM":
+			data, err = writeItems(f.Items)
+		default:
+			raw, ok := f.Raw[s]
+			if !ok {
+				return fmt.Errorf("dta: nothing to write for section %s", s)
+			}
+			data = raw
+		}
+		if err != nil {
+			return fmt.Errorf("dta: writing %s: %w", s, err)
+		}
+		if s != "ZONE" {
+			out.section(s, data)
+		}
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func writeSounds(sounds []string) []byte {
+	b := &builder{}
+	// The count is stored as a negative number
+	b.uint16(-len(sounds))
+	for _, s := range sounds {
+		// Lengths include the null terminator
+		b.uint16(len(s) + 1)
+		b.WriteString(s)
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+func writeTiles(tiles []TileInfo) ([]byte, error) {
+	b := &builder{}
+	for _, t := range tiles {
+		if len(t.Pixels) != TilePixels {
+			return nil, fmt.Errorf("tile %d has %d pixels, want %d", t.Id, len(t.Pixels), TilePixels)
+		}
+		b.uint32(uint32(t.Flags))
+		b.Write(t.Pixels)
+	}
+	return b.Bytes(), nil
+}
+
+func writeZones(out *builder, zones []ZoneInfo, game Game) error {
+	out.uint16(len(zones))
+	for _, z := range zones {
+		zb, err := writeZone(z, game)
+		if err != nil {
+			return fmt.Errorf("zone %d: %w", z.Id, err)
+		}
+		if game == Yoda {
+			out.uint16(z.Planet)
+			out.uint32(uint32(len(zb)))
+		}
+		out.Write(zb)
+	}
+	return nil
+}
+
+func writeZone(z ZoneInfo, game Game) ([]byte, error) {
+	numTiles := z.Width * z.Height
+	if len(z.TileMaps.Terrain) != numTiles || len(z.TileMaps.Walls) != numTiles || len(z.TileMaps.Overlay) != numTiles {
+		return nil, fmt.Errorf("tilemaps don't match the %dx%d zone size", z.Width, z.Height)
+	}
+
+	b := &builder{}
+	b.uint16(z.Id)
+	b.WriteString("IZON")
+	// The IZON header plus the tilemaps. Keep the size the zone came with, unless it's new or
+	// has been resized since: that's off by whole rows of tiles, not the odd byte or two.
+	izonSize := 20 + (6 * numTiles)
+	if game == Indy {
+		izonSize -= 4
+	}
+	if diff := z.IzonSize - izonSize; z.IzonSize != 0 && diff > -6 && diff < 6 {
+		izonSize = z.IzonSize
+	}
+	b.uint32(uint32(izonSize))
+	b.uint16(z.Width)
+	b.uint16(z.Height)
+	b.uint32(uint32(zoneTypeId(z, game)))
+	if game == Yoda {
+		b.uint16(z.SharedCounter)
+		b.uint16(zoneBiomeId(z))
+	}
+	for j := 0; j < numTiles; j++ {
+		b.uint16(z.TileMaps.Terrain[j])
+		b.uint16(z.TileMaps.Walls[j])
+		b.uint16(z.TileMaps.Overlay[j])
+	}
+
+	if game == Indy {
+		// The rest goes in the ZAUX, HTSP, etc. sections
+		return b.Bytes(), nil
+	}
+
+	writeHotspots(b, z.Hotspots)
+
+	// IZAX, IZX2, IZX3 and IZX4 count their headers in their lengths
+	aux, err := writeZoneActors(z)
+	if err != nil {
+		return nil, err
+	}
+	b.auxSection("IZAX", aux)
+	b.auxSection("IZX2", writeZoneIds(z.RewardItems))
+	b.auxSection("IZX3", writeZoneIds(z.QuestNPCs))
+	if aux, err = writeIzx4(z); err != nil {
+		return nil, err
+	}
+	b.auxSection("IZX4", aux)
+
+	for _, trg := range z.ActionTriggers {
+		b.section("IACT", writeTrigger(trg))
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeHotspots(b *builder, hotspots []ZoneHotspot) {
+	b.uint16(len(hotspots))
+	for _, hs := range hotspots {
+		b.uint32(uint32(hs.Type))
+		b.uint16(hs.X)
+		b.uint16(hs.Y)
+		b.uint16(hs.Enabled)
+		b.uint16(hs.Arg)
+	}
+}
+
+func writeZoneActors(z ZoneInfo) ([]byte, error) {
+	aux := &builder{}
+	aux.uint16(z.IzaxUnknown)
+	aux.uint16(len(z.ZoneActors))
+	for _, zax := range z.ZoneActors {
+		aux.uint16(zax.CreatureId)
+		aux.uint16(zax.ZoneX)
+		aux.uint16(zax.ZoneY)
+		if len(zax.Args) != 6 {
+			return nil, fmt.Errorf("actor %d has %d bytes of args, want 6", zax.Index, len(zax.Args))
+		}
+		aux.Write(zax.Args)
+		if zax.Unknown == nil {
+			aux.Write(bytes.Repeat([]byte{0xFF}, 32))
+		} else if len(zax.Unknown) == 32 {
+			aux.Write(zax.Unknown)
+		} else {
+			return nil, fmt.Errorf("actor %d has %d unknown bytes, want 32", zax.Index, len(zax.Unknown))
+		}
+	}
+	return aux.Bytes(), nil
+}
+
+func writeZoneIds(ids []int) []byte {
+	aux := &builder{}
+	aux.uint16(len(ids))
+	for _, id := range ids {
+		aux.uint16(id)
+	}
+	return aux.Bytes()
+}
+
+func writeIzx4(z ZoneInfo) ([]byte, error) {
+	izx4b, err := strconv.ParseUint(reverse(strings.NewReplacer("Y", "1", ".", "0").Replace(z.Izx4b)), 2, 16)
+	if z.Izx4b == "" {
+		izx4b, err = 0, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("IZX4 flags: %w", err)
+	}
+	aux := &builder{}
+	aux.uint16(z.Izx4a)
+	aux.uint16(int(izx4b))
+	return aux.Bytes(), nil
+}
+
+// Indy's zone sections: either one entry per zone, or entries by zone ID
+func writeZoneExtras(section string, zones []ZoneInfo) ([]byte, error) {
+	b := &builder{}
+	for _, z := range zones {
+		var err error
+		switch section {
+		case "ZAUX":
+			var aux []byte
+			if aux, err = writeZoneActors(z); err == nil {
+				b.auxSection("IZAX", aux)
+			}
+		case "ZAX2":
+			b.auxSection("IZX2", writeZoneIds(z.RewardItems))
+		case "ZAX3":
+			b.auxSection("IZX3", writeZoneIds(z.QuestNPCs))
+		case "ZAX4":
+			var aux []byte
+			if aux, err = writeIzx4(z); err == nil {
+				b.auxSection("IZX4", aux)
+			}
+		case "HTSP":
+			b.uint16(z.Id)
+			writeHotspots(b, z.Hotspots)
+		case "ACTN":
+			b.uint16(z.Id)
+			b.uint16(len(z.ActionTriggers))
+			for _, trg := range z.ActionTriggers {
+				b.section("IACT", writeTrigger(trg))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", z.Id, err)
+		}
+	}
+	if section == "HTSP" || section == "ACTN" {
+		b.uint16(0xFFFF)
+	}
+	return b.Bytes(), nil
+}
+
+func zoneTypeId(z ZoneInfo, game Game) int {
+	for zt := 0; zt < 32; zt++ {
+		if name, _ := zoneType(game, zt); name != "" && name == z.Type {
+			return zt
+		}
+	}
+	return z.TypeId
+}
+
+func zoneBiomeId(z ZoneInfo) int {
+	for p := 0; p < 16; p++ {
+		if name := zoneBiome(p); name != "UNKNOWN" && name == z.Biome {
+			return p
+		}
+	}
+	return z.BiomeId
+}
+
+func writeTrigger(trg ActionTrigger) []byte {
+	b := &builder{}
+	b.uint16(len(trg.Conditions))
+	for _, con := range trg.Conditions {
+		b.uint16(int(con.Condition))
+		for y := 0; y < 6; y++ {
+			b.uint16(argAt(con.Args, y))
+		}
+	}
+	b.uint16(len(trg.Actions))
+	for _, actn := range trg.Actions {
+		b.uint16(int(actn.Action))
+		for y := 0; y < 5; y++ {
+			b.uint16(argAt(actn.Args, y))
+		}
+		b.uint16(len(actn.Text))
+		b.WriteString(actn.Text)
+	}
+	return b.Bytes()
+}
+
+// Unused args can be left off the end
+func argAt(args []int, i int) int {
+	if i < len(args) {
+		return args[i]
+	}
+	return 0
+}
+
+var puzzleTypeIds = map[string]uint32{
+	"ItemForItem":  0x00,
+	"ItemForTask":  0x01,
+	"ItemForTask2": 0x02,
+	"MainQuest":    0x03,
+}
+
+var puzzleItemTypeIds = map[string]uint32{
+	"Keycard":  0x00,
+	"Tool":     0x01,
+	"Part":     0x02,
+	"PlotItem": 0x04,
+}
+
+func writePuzzles(puzzles []PuzzleInfo, game Game) ([]byte, error) {
+	b := &builder{}
+	for _, p := range puzzles {
+		pb := &builder{}
+		// Indy doesn't have puzzle types, or a reward item
+		unknownSize := 2
+		if game == Yoda {
+			pb.uint32(puzzleTypeIds[p.Type])
+			itemType, ok := puzzleItemTypeIds[p.ItemType]
+			if !ok {
+				return nil, fmt.Errorf("puzzle %d has unknown item type %q", p.Id, p.ItemType)
+			}
+			pb.uint32(itemType)
+			unknownSize = 6
+		}
+		if p.Unknown == nil {
+			pb.Write(make([]byte, unknownSize))
+		} else {
+			pb.Write(p.Unknown)
+		}
+		for _, s := range puzzleTexts(p) {
+			pb.uint16(len(s))
+			pb.WriteString(s)
+		}
+		pb.uint16(p.LockItemId)
+		if game == Yoda {
+			reward := p.RewardItemId
+			if reward == 0 {
+				flags, err := strconv.ParseUint(reverse(p.RewardFlags), 2, 16)
+				if p.RewardFlags == "" {
+					flags, err = 0, nil
+				}
+				if err != nil {
+					return nil, fmt.Errorf("puzzle %d reward flags: %w", p.Id, err)
+				}
+				reward = int(flags)
+			}
+			pb.uint16(reward)
+		}
+
+		b.uint16(p.Id)
+		b.section("IPUZ", pb.Bytes())
+	}
+	b.uint16(0xFFFF)
+	return b.Bytes(), nil
+}
+
+// Put NeedText, DoneText and HaveText back where they came from
+func puzzleTexts(p PuzzleInfo) []string {
+	texts := make([]string, len(p.Texts))
+	copy(texts, p.Texts)
+	first, last := puzzleTextSpan(texts)
+	if first == last {
+		// Nothing to go on, so use the usual layout: the three strings, then two empty ones
+		texts = make([]string, 0, 5)
+		for _, s := range []string{p.NeedText, p.DoneText, p.HaveText} {
+			if s != "" {
+				texts = append(texts, s)
+			}
+		}
+		for len(texts) < 5 {
+			texts = append(texts, "")
+		}
+		return texts
+	}
+
+	ret := texts[first:last]
+	switch len(ret) {
+	case 3:
+		ret[0], ret[1], ret[2] = p.NeedText, p.DoneText, p.HaveText
+	case 2:
+		ret[0], ret[1] = p.DoneText, p.HaveText
+	case 1:
+		ret[0] = p.HaveText
+	}
+	return texts
+}
+
+func writeCreatures(creatures []CreatureInfo, game Game) ([]byte, error) {
+	b := &builder{}
+	for _, c := range creatures {
+		cb := &builder{}
+		if err := cb.fixedString(c.Name, 16); err != nil {
+			return nil, fmt.Errorf("creature %d: %w", c.Id, err)
+		}
+		cb.uint16(int(c.Type))
+		cb.uint16(int(c.Movement))
+		if game == Yoda && c.Unknown == nil {
+			cb.Write(make([]byte, 6))
+		} else if game == Yoda {
+			cb.Write(c.Unknown)
+		}
+		for _, frames := range []map[CardinalDirection]int{c.Images, c.WalkImages, c.AttackImages} {
+			for _, dir := range creatureFrameOrder {
+				img, ok := frames[dir]
+				if !ok {
+					img = 0xFFFF
+				}
+				cb.uint16(img)
+			}
+		}
+		cb.Write(c.ExtraFrames)
+
+		b.uint16(c.Id)
+		b.section("ICHA", cb.Bytes())
+	}
+	b.uint16(0xFFFF)
+	return b.Bytes(), nil
+}
+
+func writeCreatureWeapons(creatures []CreatureInfo) []byte {
+	b := &builder{}
+	for _, c := range creatures {
+		b.uint16(c.Id)
+		b.uint16(c.Reference)
+		b.uint16(c.Health)
+	}
+	b.uint16(0xFFFF)
+	return b.Bytes()
+}
+
+func writeCreatureDamage(creatures []CreatureInfo) []byte {
+	b := &builder{}
+	for _, c := range creatures {
+		b.uint16(c.Id)
+		b.uint16(c.Damage)
+	}
+	b.uint16(0xFFFF)
+	return b.Bytes()
+}
+
+func writeItems(items []ItemInfo) ([]byte, error) {
+	b := &builder{}
+	for _, i := range items {
+		b.uint16(i.Id)
+		if err := b.fixedString(i.Name, 24); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i.Id, err)
+		}
+	}
+	b.uint16(0xFFFF)
+	return b.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package dta
+
+import (
+	"bytes"
+	"testing"
+)
+
+// A small file with a bit of everything in it, for either game
+func synthFile(game Game) *File {
+	f := &File{Game: game, Version: 0x200, Raw: map[string][]byte{"ENDF": {0, 0, 0, 0}}}
+	f.Startup = bytes.Repeat([]byte{7}, 288*288)
+	f.Sounds = []string{"sfx\\a.wav", "b.wav"}
+	for i := 0; i < 3; i++ {
+		f.Tiles = append(f.Tiles, TileInfo{Id: i, Flags: TileFloor | FloorDoorway, Pixels: bytes.Repeat([]byte{byte(i)}, TilePixels)})
+	}
+
+	z := ZoneInfo{Id: 0, Width: 2, Height: 2, Type: "Plain", Biome: "desert", SharedCounter: 0xFFFF, Planet: 1, Izx4a: 3, Izx4b: "Y.Y............."}
+	z.TileMaps.Terrain = []int{1, 2, 3, 0xFFFF}
+	z.TileMaps.Walls = []int{1, 2, 3, 0xFFFF}
+	z.TileMaps.Overlay = []int{1, 2, 3, 0xFFFF}
+	z.Hotspots = []ZoneHotspot{{Type: 9, X: 1, Y: 1, Enabled: 1, Arg: 5}}
+	z.ZoneActors = []ZoneActor{
+		{CreatureId: 1, ZoneX: 1, Args: []byte{1, 2, 3, 4, 5, 6}},
+		{CreatureId: 2, Args: make([]byte, 6), Unknown: make([]byte, 32)},
+	}
+	z.RewardItems = []int{1, 2}
+	z.QuestNPCs = []int{3}
+	z.ActionTriggers = []ActionTrigger{{
+		Conditions: []TriggerCondition{{Condition: BumpTile, Args: []int{1, 2, 3, 4, 5, 6}}},
+		// Latin-1, like the original's text
+		Actions: []TriggerAction{{Action: PlayerSay, Args: []int{0, 0, 0, 0, 0}, Text: "Hello\r\nthere \xe9"}},
+	}}
+	f.Zones = []ZoneInfo{z, z}
+	f.Zones[1].Id = 1
+
+	f.Puzzles = []PuzzleInfo{
+		{Id: 0, Type: "MainQuest", ItemType: "Tool", NeedText: "n", DoneText: "d", HaveText: "h", LockItemId: 2, RewardItemId: 1},
+		{Id: 1, Type: "ItemForItem", ItemType: "Part", HaveText: "h2", RewardFlags: "1000000000000000"},
+	}
+	f.Creatures = []CreatureInfo{{
+		Id: 0, Name: "Luke", Type: CreatureHero, Movement: MoveWander, Unknown: make([]byte, 6),
+		Images: map[CardinalDirection]int{Down: 2}, WalkImages: map[CardinalDirection]int{Up: 7},
+		Reference: 65535, Health: 300, Damage: -5,
+	}}
+	f.Items = []ItemInfo{{Id: 1, Name: "Thing"}}
+
+	if game == Indy {
+		// No biomes, planets or puzzle types, and zone types are only known by number
+		f.Version = 0x100
+		for i := range f.Zones {
+			f.Zones[i].Type, f.Zones[i].TypeId, f.Zones[i].Biome, f.Zones[i].Planet = "", 7, "", 0
+			f.Zones[i].Hotspots = []ZoneHotspot{}
+			f.Zones[i].ActionTriggers = []ActionTrigger{}
+		}
+		f.Creatures[0].Unknown = nil
+		f.Puzzles[0].Type, f.Puzzles[0].ItemType, f.Puzzles[0].RewardItemId = "", "", 0
+		f.Puzzles[1].Type, f.Puzzles[1].ItemType, f.Puzzles[1].RewardFlags = "", "", ""
+	}
+	return f
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, game := range []Game{Yoda, Indy} {
+		var first, second bytes.Buffer
+		if err := Write(&first, synthFile(game)); err != nil {
+			t.Fatalf("%s: writing: %v", game.ToString(), err)
+		}
+		f, err := Parse(bytes.NewReader(first.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: parsing: %v", game.ToString(), err)
+		}
+		if f.Game != game {
+			t.Errorf("%s: parsed as %s", game.ToString(), f.Game.ToString())
+		}
+		if err := Write(&second, f); err != nil {
+			t.Fatalf("%s: writing again: %v", game.ToString(), err)
+		}
+		if !bytes.Equal(first.Bytes(), second.Bytes()) {
+			t.Errorf("%s: round trip changed the file: %d bytes, then %d", game.ToString(), first.Len(), second.Len())
+		}
+	}
+}
+
+func TestResizedZoneSize(t *testing.T) {
+	for _, game := range []Game{Yoda, Indy} {
+		var buf bytes.Buffer
+		if err := Write(&buf, synthFile(game)); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Parse(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Like a zone made bigger in Tiled and loaded back, which keeps its old IzonSize
+		z := &f.Zones[0]
+		z.Width = 3
+		z.TileMaps.Terrain = append(z.TileMaps.Terrain, 0, 0)
+		z.TileMaps.Walls = append(z.TileMaps.Walls, 0, 0)
+		z.TileMaps.Overlay = append(z.TileMaps.Overlay, 0, 0)
+		buf.Reset()
+		if err := Write(&buf, f); err != nil {
+			t.Fatal(err)
+		}
+		f, err = Parse(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: parsing the resized zone: %v", game.ToString(), err)
+		}
+
+		want := 20 + 6*3*2
+		if game == Indy {
+			want -= 4
+		}
+		if got := f.Zones[0].IzonSize; got != want {
+			t.Errorf("%s: IZON size is %d, want %d", game.ToString(), got, want)
+		}
+		if got := f.Zones[0].Width; got != 3 {
+			t.Errorf("%s: width is %d, want 3", game.ToString(), got)
+		}
+	}
+}
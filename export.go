@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
+	"image"
+	"image/png"
 	"log"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/MasterShizzle/goda-stories/gosoh"
+	"github.com/MasterShizzle/goda-stories/maprender"
 	"github.com/davecgh/go-spew/spew"
 )
 
@@ -54,6 +60,7 @@ type TiledXMLMap struct {
 type TiledXMLProperty struct {
 	XMLName xml.Name
 	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr,omitempty"` // Tiled assumes "string"
 	Value   string `xml:"value,attr"`
 }
 
@@ -69,11 +76,11 @@ type TiledXMLObject struct {
 	Id         int                `xml:"id,attr"`
 	Name       string             `xml:"name,attr"`
 	Type       int                `xml:"type,attr"`
-	X          int                `xml:"x,attr"`
-	Y          int                `xml:"y,attr"`
-	Width      int                `xml:"width,attr"`
-	Height     int                `xml:"height,attr"`
-	Rotation   int                `xml:"rotation,attr"`
+	X          float64            `xml:"x,attr"` // Tiled writes these as floats once something's been moved off the grid
+	Y          float64            `xml:"y,attr"`
+	Width      float64            `xml:"width,attr"`
+	Height     float64            `xml:"height,attr"`
+	Rotation   float64            `xml:"rotation,attr"`
 	Properties []TiledXMLProperty `xml:"properties>property"`
 	TileGid    int                `xml:"gid,attr"`
 	Mark       TiledXMLShape      `xml:"ellipse"`
@@ -83,6 +90,51 @@ type TiledXMLShape struct {
 	XMLName xml.Name
 }
 
+type TiledXMLTileset struct {
+	XMLName      xml.Name
+	Version      string         `xml:"version,attr"`
+	TiledVersion string         `xml:"tiledversion,attr"`
+	Name         string         `xml:"name,attr"`
+	TileWidth    int            `xml:"tilewidth,attr"`
+	TileHeight   int            `xml:"tileheight,attr"`
+	TileCount    int            `xml:"tilecount,attr"`
+	Columns      int            `xml:"columns,attr"`
+	Image        TiledXMLImage  `xml:"image"`
+	Tiles        []TiledXMLTile `xml:"tile"`
+}
+
+type TiledXMLImage struct {
+	XMLName xml.Name
+	Source  string `xml:"source,attr"`
+	Trans   string `xml:"trans,attr"`
+	Width   int    `xml:"width,attr"`
+	Height  int    `xml:"height,attr"`
+}
+
+type TiledXMLTile struct {
+	XMLName    xml.Name
+	Id         int                `xml:"id,attr"`
+	Type       string             `xml:"type,attr,omitempty"`
+	Properties []TiledXMLProperty `xml:"properties>property"`
+	Collision  *TiledXMLCollision `xml:"objectgroup,omitempty"`
+}
+
+// A tile's collision shapes are an object group inside the <tile>
+type TiledXMLCollision struct {
+	XMLName   xml.Name
+	DrawOrder string               `xml:"draworder,attr"`
+	Objects   []TiledXMLTileObject `xml:"object"`
+}
+
+type TiledXMLTileObject struct {
+	XMLName xml.Name
+	Id      int `xml:"id,attr"`
+	X       int `xml:"x,attr"`
+	Y       int `xml:"y,attr"`
+	Width   int `xml:"width,attr"`
+	Height  int `xml:"height,attr"`
+}
+
 type TiledTilesetRef struct {
 	XMLName  xml.Name
 	FirstGid int    `xml:"firstgid,attr"`
@@ -104,8 +156,47 @@ type TiledCSVTileMapData struct {
 	Body     string `xml:",chardata"`
 }
 
+// The tileset image that the .tsx points to
+func saveTilesetImage(img image.Image) error {
+	if err := savePNG(tilesetImagePath, img); err != nil {
+		return err
+	}
+	fmt.Printf("[saveTilesetImage] Saved tileset image: %s\n", tilesetImagePath)
+	return nil
+}
+
+// The area the player starts in, as one big PNG with every overlay on
+func saveMapImage(g *Game, filepath string) error {
+	img := g.World.GetCurrentArea().RenderImage(maprender.Options{
+		ZoneBorders: true,
+		Hotspots:    true,
+		Actors:      true,
+		Blocked:     true,
+	})
+	if err := savePNG(filepath, img); err != nil {
+		return err
+	}
+	fmt.Printf("[saveMapImage] Saved map image: %s\n", filepath)
+	return nil
+}
+
+func savePNG(filepath string, img image.Image) error {
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func saveTiledMaps(g *Game) {
 	// Save Tileset and Zones to Tiled-compatible files
+	if err := saveTiledTileset("assets/"+tilesetFileName, gosoh.TileInfos); err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println("[saveTiledMaps] Stitching Zones to Tiled maps...")
 	for zId, zData := range gosoh.Zones {
 		mapNum := fmt.Sprintf("%03d", zId)
@@ -115,6 +206,84 @@ func saveTiledMaps(g *Game) {
 	fmt.Printf("    %d zones extracted.\n", len(gosoh.Zones))
 }
 
+// Build the .tsx from the tile data, so Tiled knows what each tile is.
+// Tiles you can't walk on get a collision box covering the whole tile.
+func saveTiledTileset(filepath string, tiles []gosoh.TileInfo) error {
+	tileRows := int(len(tiles)/gosoh.TilesetColumns) + 1
+	tsx := TiledXMLTileset{
+		XMLName:      xml.Name{Local: "tileset"},
+		Version:      "1.5",
+		TiledVersion: "1.7.2",
+		Name:         strings.TrimSuffix(tilesetFileName, ".tsx"),
+		TileWidth:    gosoh.TileWidth,
+		TileHeight:   gosoh.TileHeight,
+		TileCount:    len(tiles),
+		Columns:      gosoh.TilesetColumns,
+		Image: TiledXMLImage{
+			XMLName: xml.Name{Local: "image"},
+			Source:  path.Base(tilesetImagePath),
+			Trans:   "000000",
+			Width:   gosoh.TilesetColumns * gosoh.TileWidth,
+			Height:  tileRows * gosoh.TileHeight,
+		},
+		Tiles: make([]TiledXMLTile, 0, len(tiles)),
+	}
+
+	creatureNames := creatureTileNames()
+	for _, t := range tiles {
+		tile := TiledXMLTile{
+			XMLName: xml.Name{Local: "tile"},
+			Id:      t.Id,
+			Type:    t.Type.ToString(),
+		}
+		tile.Properties = []TiledXMLProperty{
+			{XMLName: xml.Name{Local: "property"}, Name: "Type", Value: t.Type.ToString()},
+			{XMLName: xml.Name{Local: "property"}, Name: "Walkable", Type: "bool", Value: fmt.Sprintf("%t", t.IsWalkable)},
+			{XMLName: xml.Name{Local: "property"}, Name: "Flags", Type: "int", Value: fmt.Sprintf("%d", uint32(t.Flags))},
+			{XMLName: xml.Name{Local: "property"}, Name: "FlagBits", Value: t.Flags.ToString()},
+		}
+		name := creatureNames[t.Id]
+		if itemName := gosoh.GetItemName(t.Id); itemName != "UNKNOWN" {
+			name = itemName
+		}
+		if name != "" {
+			tile.Properties = append(tile.Properties, TiledXMLProperty{
+				XMLName: xml.Name{Local: "property"}, Name: "Name", Value: name,
+			})
+		}
+		if !t.IsWalkable {
+			tile.Collision = &TiledXMLCollision{
+				XMLName:   xml.Name{Local: "objectgroup"},
+				DrawOrder: "index",
+				Objects: []TiledXMLTileObject{{
+					XMLName: xml.Name{Local: "object"},
+					Id:      1,
+					Width:   gosoh.TileWidth,
+					Height:  gosoh.TileHeight,
+				}},
+			}
+		}
+		tsx.Tiles = append(tsx.Tiles, tile)
+	}
+
+	return saveXMLToFile(filepath, tsx)
+}
+
+// Which creature each tile belongs to, going by their frames
+func creatureTileNames() map[int]string {
+	ret := make(map[int]string)
+	for _, c := range gosoh.Creatures {
+		for _, frames := range []map[gosoh.CardinalDirection]int{c.Images, c.WalkImages, c.AttackImages} {
+			for _, tNum := range frames {
+				if _, ok := ret[tNum]; !ok && tNum != 65535 {
+					ret[tNum] = c.Name
+				}
+			}
+		}
+	}
+	return ret
+}
+
 func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 	zoneXML := TiledXMLMap{
 		XMLName:         xml.Name{Local: "map"},
@@ -158,6 +327,32 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 		Value:   fmt.Sprintf("%t", zData.IsOverworld),
 	}
 	zoneXML.Properties = append(zoneXML.Properties, zProp)
+	// Everything else we need to turn the map back into the same zone (see loadTiledMap)
+	for _, p := range []struct {
+		name  string
+		value int
+	}{
+		{"Id", zData.Id},
+		{"TypeId", zData.TypeId},
+		{"BiomeId", zData.BiomeId},
+		{"Planet", zData.Planet},
+		{"IzonSize", zData.IzonSize},
+		{"SharedCounter", zData.SharedCounter},
+		{"IzaxUnknown", zData.IzaxUnknown},
+		{"Izx4a", zData.Izx4a},
+	} {
+		zoneXML.Properties = append(zoneXML.Properties, TiledXMLProperty{
+			XMLName: xml.Name{Local: "property"},
+			Name:    p.name,
+			Type:    "int",
+			Value:   fmt.Sprintf("%d", p.value),
+		})
+	}
+	zoneXML.Properties = append(zoneXML.Properties, TiledXMLProperty{
+		XMLName: xml.Name{Local: "property"},
+		Name:    "Izx4b",
+		Value:   zData.Izx4b,
+	})
 
 	zoneXML.Objects = make([]TiledXMLObjectGroup, 0)
 
@@ -199,28 +394,36 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 			Id:       zoneXML.NextObjectId,
 			Name:     hs.ToString(),
 			Type:     int(hs.Type),
-			X:        (hs.X * gosoh.TileWidth) + 2,
-			Y:        (hs.Y * gosoh.TileHeight) + 2,
-			Width:    gosoh.TileWidth - 4,
-			Height:   gosoh.TileHeight - 4,
+			X:        float64(hs.X*gosoh.TileWidth) + 2,
+			Y:        float64(hs.Y*gosoh.TileHeight) + 2,
+			Width:    float64(gosoh.TileWidth - 4),
+			Height:   float64(gosoh.TileHeight - 4),
 			Rotation: 0,
+			Properties: []TiledXMLProperty{
+				{XMLName: xml.Name{Local: "property"}, Name: "Enabled", Type: "int", Value: fmt.Sprintf("%d", hs.Enabled)},
+				{XMLName: xml.Name{Local: "property"}, Name: "Arg", Type: "int", Value: fmt.Sprintf("%d", hs.Arg)},
+			},
 		}
 		hotspots.Objects = append(hotspots.Objects, spot)
 		zoneXML.NextObjectId++
 	}
 	for _, act := range zData.ZoneActors {
-		crtr := gosoh.Creatures[act.CreatureId]
+		crtr := gosoh.GetCreatureInfo(act.CreatureId)
 		actor := TiledXMLObject{
 			XMLName:  xml.Name{Local: "object"},
 			Id:       zoneXML.NextObjectId,
 			Name:     crtr.Name,
 			Type:     act.CreatureId,
-			X:        (act.ZoneX * gosoh.TileWidth),
-			Y:        (act.ZoneY * gosoh.TileHeight),
-			Width:    gosoh.TileWidth,
-			Height:   gosoh.TileHeight,
+			X:        float64(act.ZoneX * gosoh.TileWidth),
+			Y:        float64(act.ZoneY * gosoh.TileHeight),
+			Width:    float64(gosoh.TileWidth),
+			Height:   float64(gosoh.TileHeight),
 			Rotation: 0,
 			TileGid:  gosoh.GetCreatureTNum(act.CreatureId) + 1,
+			Properties: []TiledXMLProperty{
+				{XMLName: xml.Name{Local: "property"}, Name: "Args", Value: hex.EncodeToString(act.Args)},
+				{XMLName: xml.Name{Local: "property"}, Name: "Unknown", Value: hex.EncodeToString(act.Unknown)},
+			},
 		}
 		actors.Objects = append(actors.Objects, actor)
 		zoneXML.NextObjectId++
@@ -231,10 +434,10 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 			Id:       zoneXML.NextObjectId,
 			Name:     fmt.Sprintf("NPC %d", i),
 			Type:     npcId,
-			X:        (i * gosoh.TileWidth),
-			Y:        (-1 * gosoh.TileHeight),
-			Width:    gosoh.TileWidth,
-			Height:   gosoh.TileHeight,
+			X:        float64(i * gosoh.TileWidth),
+			Y:        float64(-1 * gosoh.TileHeight),
+			Width:    float64(gosoh.TileWidth),
+			Height:   float64(gosoh.TileHeight),
 			Rotation: 0,
 			TileGid:  npcId + 1,
 		}
@@ -247,10 +450,10 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 			Id:       zoneXML.NextObjectId,
 			Name:     gosoh.GetItemName(rewardId),
 			Type:     rewardId,
-			X:        (i * gosoh.TileWidth),
-			Y:        (-3 * gosoh.TileHeight),
-			Width:    gosoh.TileWidth,
-			Height:   gosoh.TileHeight,
+			X:        float64(i * gosoh.TileWidth),
+			Y:        float64(-3 * gosoh.TileHeight),
+			Width:    float64(gosoh.TileWidth),
+			Height:   float64(gosoh.TileHeight),
 			Rotation: 0,
 			TileGid:  rewardId + 1,
 		}
@@ -263,10 +466,10 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 			Id:         zoneXML.NextObjectId,
 			Name:       fmt.Sprintf("Trg_%d", i),
 			Type:       i,
-			X:          (i * gosoh.TileWidth),
-			Y:          ((zData.Height + 1) * gosoh.TileHeight),
-			Width:      gosoh.TileWidth,
-			Height:     gosoh.TileHeight,
+			X:          float64(i * gosoh.TileWidth),
+			Y:          float64((zData.Height + 1) * gosoh.TileHeight),
+			Width:      float64(gosoh.TileWidth),
+			Height:     float64(gosoh.TileHeight),
 			Rotation:   0,
 			Properties: make([]TiledXMLProperty, 0),
 		}
@@ -284,7 +487,7 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 			prop := TiledXMLProperty{
 				XMLName: xml.Name{Local: "property"},
 				Name:    fmt.Sprintf("THEN_%03d", j),
-				Value:   a.ToString(),
+				Value:   latin1ToUTF8(a.ToString()),
 			}
 			trgr.Properties = append(trgr.Properties, prop)
 		}
@@ -353,6 +556,16 @@ func saveZoneToTiledMap(filepath string, zData gosoh.ZoneInfo) {
 	}
 }
 
+// The game's text is single bytes (mostly Windows-1252), which isn't valid UTF-8 for XML.
+// Mapping each byte to the rune with the same number keeps it readable, and is easy to undo.
+func latin1ToUTF8(s string) string {
+	r := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		r[i] = rune(s[i])
+	}
+	return string(r)
+}
+
 func getLayerCSV(layerData []int) (ret string) {
 	ret = ""
 
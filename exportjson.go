@@ -0,0 +1,434 @@
+package main
+
+// `export json`: dump the whole parsed data file as one JSON document.
+// The layout is described by docs/gamedata.schema.json; if it changes, bump jsonSchemaVersion.
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+const jsonSchemaVersion = 1
+const jsonSchemaId = "https://github.com/MasterShizzle/goda-stories/blob/main/docs/gamedata.schema.json"
+
+// Tile IDs, creature IDs etc. use 65535 for "nothing"; in JSON that's null
+const noId = 65535
+
+// Everything here mirrors the dta model, but with names and a shape we promise to keep.
+// IDs are the game's own: a tile's ID is its index in TILE, a zone's is its index in ZONE, and so on.
+type jsonGameData struct {
+	Schema        string         `json:"$schema"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Game          string         `json:"game"`
+	Version       string         `json:"version"`
+	Tiles         []jsonTile     `json:"tiles"`
+	Zones         []jsonZone     `json:"zones"`
+	Puzzles       []jsonPuzzle   `json:"puzzles"`
+	Items         []jsonItem     `json:"items"`
+	Creatures     []jsonCreature `json:"creatures"`
+	Sounds        []jsonSound    `json:"sounds"`
+}
+
+type jsonTile struct {
+	Id        int      `json:"id"`
+	Flags     uint32   `json:"flags"`
+	FlagNames []string `json:"flagNames"`
+	Type      string   `json:"type"`
+	Walkable  bool     `json:"walkable"`
+}
+
+type jsonZone struct {
+	Id          int           `json:"id"`
+	Biome       string        `json:"biome"`
+	Type        string        `json:"type"`
+	TypeId      int           `json:"typeId"`
+	Overworld   bool          `json:"overworld"`
+	Width       int           `json:"width"`
+	Height      int           `json:"height"`
+	Terrain     []*int        `json:"terrain"`
+	Walls       []*int        `json:"walls"`
+	Overlay     []*int        `json:"overlay"`
+	Hotspots    []jsonHotspot `json:"hotspots"`
+	Actors      []jsonActor   `json:"actors"`
+	Rewards     []int         `json:"rewardItems"`
+	QuestNPCs   []int         `json:"questNPCs"`
+	Triggers    []jsonTrigger `json:"triggers"`
+	Izx4a       int           `json:"izx4a"`
+	Izx4b       string        `json:"izx4b"`
+	PlanetId    int           `json:"planetId"`
+	BiomeId     int           `json:"biomeId"`
+	IzaxUnknown int           `json:"izaxUnknown"`
+}
+
+type jsonHotspot struct {
+	Index   int    `json:"index"` // Position in the zone's list; scripts refer to hotspots by this
+	Type    string `json:"type"`
+	TypeId  int    `json:"typeId"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Enabled int    `json:"enabled"`
+	Arg     *int   `json:"arg"`
+}
+
+type jsonActor struct {
+	Index      int   `json:"index"`
+	CreatureId *int  `json:"creatureId"`
+	X          int   `json:"x"`
+	Y          int   `json:"y"`
+	Args       []int `json:"args"`
+}
+
+type jsonTrigger struct {
+	Index      int             `json:"index"`
+	Conditions []jsonCondition `json:"conditions"`
+	Actions    []jsonAction    `json:"actions"`
+}
+
+type jsonCondition struct {
+	Opcode int    `json:"opcode"`
+	Name   string `json:"name"`
+	Args   []int  `json:"args"`
+}
+
+type jsonAction struct {
+	Opcode int    `json:"opcode"`
+	Name   string `json:"name"`
+	Args   []int  `json:"args"`
+	Text   string `json:"text"`
+}
+
+type jsonPuzzle struct {
+	Id           int      `json:"id"`
+	Type         string   `json:"type"`
+	ItemType     string   `json:"itemType"`
+	NeedText     string   `json:"needText"`
+	HaveText     string   `json:"haveText"`
+	DoneText     string   `json:"doneText"`
+	LockItemId   *int     `json:"lockItemId"`
+	RewardItemId *int     `json:"rewardItemId"`
+	Texts        []string `json:"texts"`
+}
+
+type jsonItem struct {
+	TileId int    `json:"tileId"`
+	Name   string `json:"name"`
+}
+
+type jsonCreature struct {
+	Id        int                     `json:"id"`
+	Name      string                  `json:"name"`
+	Type      string                  `json:"type"`
+	Movement  string                  `json:"movement"`
+	Frames    map[string]jsonFrameSet `json:"frames"`
+	Reference *int                    `json:"reference"` // Weapon creature ID, or a weapon's sound ID
+	Health    int                     `json:"health"`
+	Damage    int                     `json:"damage"`
+}
+
+// Tile ID for each direction
+type jsonFrameSet map[string]*int
+
+type jsonSound struct {
+	Id   int    `json:"id"`
+	File string `json:"file"`
+}
+
+func runExport(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "json":
+			runExportJSON(args[1:])
+			return
+		case "scripts":
+			runExportScripts(args[1:])
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s export json [-o file.json]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s export scripts [-o folder]\n", os.Args[0])
+	os.Exit(2)
+}
+
+// Parse the data file straight from disk, without the cache
+func readDataFile() *dta.File {
+	fileName := findDataFile()
+	f, err := os.Open("data/" + fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := dta.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return data
+}
+
+func runExportJSON(args []string) {
+	fs := flag.NewFlagSet("export json", flag.ExitOnError)
+	outPath := fs.String("o", "", "file to write the JSON to (default: stdout)")
+	fs.Parse(args)
+
+	data := readDataFile()
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		outFile, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer outFile.Close()
+		out = outFile
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(buildJSONExport(data)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func buildJSONExport(data *dta.File) jsonGameData {
+	ret := jsonGameData{
+		Schema:        jsonSchemaId,
+		SchemaVersion: jsonSchemaVersion,
+		Game:          "yoda",
+		Version:       data.VersionString(),
+		Tiles:         make([]jsonTile, len(data.Tiles)),
+		Zones:         make([]jsonZone, len(data.Zones)),
+		Puzzles:       make([]jsonPuzzle, len(data.Puzzles)),
+		Items:         make([]jsonItem, len(data.Items)),
+		Creatures:     make([]jsonCreature, len(data.Creatures)),
+		Sounds:        make([]jsonSound, len(data.Sounds)),
+	}
+	if data.Game == dta.Indy {
+		ret.Game = "indy"
+	}
+
+	for i, t := range data.Tiles {
+		ret.Tiles[i] = jsonTile{
+			Id:        t.Id,
+			Flags:     uint32(t.Flags),
+			FlagNames: tileFlagNames(t.Flags),
+			Type:      t.Type.ToString(),
+			Walkable:  t.IsWalkable,
+		}
+	}
+	for i, z := range data.Zones {
+		ret.Zones[i] = jsonExportZone(z)
+	}
+	for i, p := range data.Puzzles {
+		ret.Puzzles[i] = jsonPuzzle{
+			Id:           p.Id,
+			Type:         p.Type,
+			ItemType:     p.ItemType,
+			NeedText:     cp1252ToUTF8(p.NeedText),
+			HaveText:     cp1252ToUTF8(p.HaveText),
+			DoneText:     cp1252ToUTF8(p.DoneText),
+			LockItemId:   jsonId(p.LockItemId),
+			RewardItemId: jsonId(p.RewardItemId),
+			Texts:        cp1252Strings(p.Texts),
+		}
+	}
+	for i, item := range data.Items {
+		ret.Items[i] = jsonItem{TileId: item.Id, Name: cp1252ToUTF8(item.Name)}
+	}
+	for i, c := range data.Creatures {
+		ret.Creatures[i] = jsonCreature{
+			Id:       c.Id,
+			Name:     cp1252ToUTF8(c.Name),
+			Type:     c.Type.ToString(),
+			Movement: c.Movement.ToString(),
+			Frames: map[string]jsonFrameSet{
+				"standing":  jsonFrames(c.Images),
+				"walking":   jsonFrames(c.WalkImages),
+				"attacking": jsonFrames(c.AttackImages),
+			},
+			Reference: jsonId(c.Reference),
+			Health:    c.Health,
+			Damage:    c.Damage,
+		}
+	}
+	for i, s := range data.Sounds {
+		ret.Sounds[i] = jsonSound{Id: i, File: cp1252ToUTF8(s)}
+	}
+	return ret
+}
+
+func jsonExportZone(z dta.ZoneInfo) jsonZone {
+	ret := jsonZone{
+		Id:          z.Id,
+		Biome:       z.Biome,
+		Type:        z.Type,
+		TypeId:      z.TypeId,
+		Overworld:   z.IsOverworld,
+		Width:       z.Width,
+		Height:      z.Height,
+		Terrain:     jsonTileMap(z.TileMaps.Terrain),
+		Walls:       jsonTileMap(z.TileMaps.Walls),
+		Overlay:     jsonTileMap(z.TileMaps.Overlay),
+		Hotspots:    make([]jsonHotspot, len(z.Hotspots)),
+		Actors:      make([]jsonActor, len(z.ZoneActors)),
+		Rewards:     nonNilInts(z.RewardItems),
+		QuestNPCs:   nonNilInts(z.QuestNPCs),
+		Triggers:    make([]jsonTrigger, len(z.ActionTriggers)),
+		Izx4a:       z.Izx4a,
+		Izx4b:       z.Izx4b,
+		PlanetId:    z.Planet,
+		BiomeId:     z.BiomeId,
+		IzaxUnknown: z.IzaxUnknown,
+	}
+	for i, hs := range z.Hotspots {
+		ret.Hotspots[i] = jsonHotspot{
+			Index:   i,
+			Type:    hs.Type.ToString(),
+			TypeId:  int(hs.Type),
+			X:       hs.X,
+			Y:       hs.Y,
+			Enabled: hs.Enabled,
+			Arg:     jsonId(hs.Arg),
+		}
+	}
+	for i, a := range z.ZoneActors {
+		args := make([]int, len(a.Args))
+		for j, b := range a.Args {
+			args[j] = int(b)
+		}
+		ret.Actors[i] = jsonActor{
+			Index:      i,
+			CreatureId: jsonId(a.CreatureId),
+			X:          a.ZoneX,
+			Y:          a.ZoneY,
+			Args:       args,
+		}
+	}
+	for i, trig := range z.ActionTriggers {
+		jt := jsonTrigger{
+			Index:      i,
+			Conditions: make([]jsonCondition, len(trig.Conditions)),
+			Actions:    make([]jsonAction, len(trig.Actions)),
+		}
+		for j, c := range trig.Conditions {
+			jt.Conditions[j] = jsonCondition{
+				Opcode: int(c.Condition),
+				Name:   c.Condition.ToString(),
+				Args:   nonNilInts(c.Args),
+			}
+		}
+		for j, a := range trig.Actions {
+			jt.Actions[j] = jsonAction{
+				Opcode: int(a.Action),
+				Name:   a.Action.ToString(),
+				Args:   nonNilInts(a.Args),
+				Text:   cp1252ToUTF8(a.Text),
+			}
+		}
+		ret.Triggers[i] = jt
+	}
+	return ret
+}
+
+// What bytes 0x80-0x9F mean in Windows-1252. The five it leaves undefined stay as the same-numbered control character.
+var cp1252High = [32]rune{
+	'\u20ac', '\u0081', '\u201a', '\u0192', '\u201e', '\u2026', '\u2020', '\u2021',
+	'\u02c6', '\u2030', '\u0160', '\u2039', '\u0152', '\u008d', '\u017d', '\u008f',
+	'\u0090', '\u2018', '\u2019', '\u201c', '\u201d', '\u2022', '\u2013', '\u2014',
+	'\u02dc', '\u2122', '\u0161', '\u203a', '\u0153', '\u009d', '\u017e', '\u0178',
+}
+
+// The game's text is Windows-1252, one byte per character; JSON wants UTF-8.
+// Unlike latin1ToUTF8 this gets the curly quotes and dashes right, but there's no importing it back.
+func cp1252ToUTF8(s string) string {
+	r := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; b >= 0x80 && b < 0xa0 {
+			r[i] = cp1252High[b-0x80]
+		} else {
+			r[i] = rune(b)
+		}
+	}
+	return string(r)
+}
+
+// Names for every flag bit that's set, including the subtype bits that mean something for this kind of tile
+func tileFlagNames(f dta.TileFlags) []string {
+	checks := []struct {
+		name string
+		is   func() bool
+	}{
+		{"Transparent", f.Transparent},
+		{"Floor", f.Floor},
+		{"Object", f.Object},
+		{"Draggable", f.Draggable},
+		{"Roof", f.Roof},
+		{"Locator", f.Locator},
+		{"Weapon", f.Weapon},
+		{"Item", f.Item},
+		{"Character", f.Character},
+		{"Doorway", f.Doorway},
+		{"Keycard", f.Keycard},
+		{"Tool", f.Tool},
+		{"Part", f.Part},
+		{"Valuable", f.Valuable},
+		{"LocatorItem", f.LocatorItem},
+		{"Edible", f.Edible},
+		{"LightBlaster", f.LightBlaster},
+		{"HeavyBlaster", f.HeavyBlaster},
+		{"Lightsaber", f.Lightsaber},
+		{"TheForce", f.TheForce},
+		{"Hero", f.Hero},
+		{"Enemy", f.Enemy},
+		{"NPC", f.NPC},
+	}
+	ret := make([]string, 0)
+	for _, c := range checks {
+		if c.is() {
+			ret = append(ret, c.name)
+		}
+	}
+	return ret
+}
+
+func jsonId(id int) *int {
+	if id == noId {
+		return nil
+	}
+	return &id
+}
+
+func jsonTileMap(tiles []int) []*int {
+	ret := make([]*int, len(tiles))
+	for i, t := range tiles {
+		ret[i] = jsonId(t)
+	}
+	return ret
+}
+
+func jsonFrames(frames map[dta.CardinalDirection]int) jsonFrameSet {
+	ret := make(jsonFrameSet)
+	for dir, t := range frames {
+		ret[dir.Name] = jsonId(t)
+	}
+	return ret
+}
+
+// So empty lists come out as [] instead of null
+func nonNilInts(s []int) []int {
+	if s == nil {
+		return []int{}
+	}
+	return s
+}
+
+// Never nil either, since make gives [] for an empty list
+func cp1252Strings(s []string) []string {
+	ret := make([]string, len(s))
+	for i, str := range s {
+		ret[i] = cp1252ToUTF8(str)
+	}
+	return ret
+}
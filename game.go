@@ -1,25 +1,80 @@
 package main
 
 import (
+	"fmt"
+	"image"
 	"math"
 
+	"github.com/MasterShizzle/goda-stories/bitmapfont"
+	"github.com/MasterShizzle/goda-stories/dta"
 	"github.com/MasterShizzle/goda-stories/gosoh"
+	"github.com/MasterShizzle/goda-stories/worldgen"
 	"github.com/blizzy78/ebitenui"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type Game struct {
-	World *GameWorld
-	Gui   *ebitenui.UI
-	View  ViewCoords
-	tick  int64
+	Options NewGameOptions // What this game was started with; Seed is the one that actually got used
+	World   *GameWorld
+	Gui     *ebitenui.UI
+	View    ViewCoords
+	Splash  *ebiten.Image // Startup screen, shown until it times out or gets skipped
+	tick    int64
 }
 
-func NewGame(tileset *ebiten.Image, tileInfo []gosoh.TileInfo, zoneInfo []gosoh.ZoneInfo, itemInfo []gosoh.ItemInfo, puzzleInfo []gosoh.PuzzleInfo, creatureInfo []gosoh.CreatureInfo, soundList []string) *Game {
+// Everything that can be picked when starting a new game
+type NewGameOptions struct {
+	Seed        int64 // 0 picks one
+	Width       int   // Size of the planet, in zones; see worldgen.Sizes
+	Height      int
+	PuzzleSteps int    // How many puzzles before the FinalDestination; 0 for the usual number for the size
+	Biome       string // The planet: "desert", "snow" or "forest" (going by ZoneInfo.Biome); "" picks one
+	MainQuest   int    // Id of the MainQuest puzzle to finish on; -1 picks one
+	Strict      bool   // If the planet can't be made like this, fail, instead of starting without one
+}
+
+// A medium planet, with everything else picked at random
+func DefaultGameOptions() NewGameOptions {
+	size := worldgen.Sizes["medium"]
+	return NewGameOptions{
+		Width:     size,
+		Height:    size,
+		MainQuest: -1,
+	}
+}
+
+func (o NewGameOptions) planetOptions() gosoh.PlanetOptions {
+	return gosoh.PlanetOptions{
+		Width:       o.Width,
+		Height:      o.Height,
+		PuzzleSteps: o.PuzzleSteps,
+		Biome:       o.Biome,
+		MainQuest:   o.MainQuest,
+	}
+}
+
+// How long to show the startup screen: 3 seconds, at 60 TPS
+const SplashTicks int64 = 180
+
+func NewGame(data *dta.File, tileset image.Image, opts NewGameOptions) (*Game, error) {
 	// TODO: Distinguish between "init game" and "new game"
 	g := &Game{}
 
-	g.Gui = buildGui()
+	// Same seed (and options), same world; this has to happen before anything rolls a number
+	gosoh.SeedRandom(opts.Seed)
+	opts.Seed = gosoh.Random.Seed
+	g.Options = opts
+	fmt.Printf("[NewGame] Seed: %d\n", opts.Seed)
+	gosoh.ResetScripts()
+	gosoh.ResetMusic()
+
+	uiFont, err := bitmapfont.Load(GuiFontFile)
+	if err != nil {
+		return nil, err
+	}
+	gosoh.UIFont = uiFont
+	g.Gui = buildGui(uiFont)
 
 	// Viewport is 12:10 ratio
 	vHeight := float64(WindowHeight - (2 * ElementBuffer))
@@ -32,40 +87,69 @@ func NewGame(tileset *ebiten.Image, tileInfo []gosoh.TileInfo, zoneInfo []gosoh.
 		Height: vHeight,
 	}
 
-	gosoh.Zones = zoneInfo
-	gosoh.Items = itemInfo
-	gosoh.Puzzles = puzzleInfo
-	gosoh.Creatures = creatureInfo
-	gosoh.Sounds = soundList
-	gosoh.TilesetImage = tileset
-	gosoh.TileInfos = tileInfo
+	gosoh.CurrentGame = data.Game
+	gosoh.Zones = data.Zones
+	gosoh.Items = data.Items
+	gosoh.Puzzles = data.Puzzles
+	gosoh.Creatures = data.Creatures
+	gosoh.Sounds = data.Sounds
+	gosoh.TilesetImage = ebiten.NewImageFromImage(tileset)
+	gosoh.TileInfos = data.Tiles
+
+	if len(data.Startup) > 0 {
+		g.Splash = ebiten.NewImageFromImage(data.StartupImage())
+	}
 
-	g.World = NewWorld()
+	if g.World, err = NewWorld(opts); err != nil {
+		return nil, err
+	}
 
 	// ECS!
 	gosoh.InitializeECS()
+	gosoh.SpawnZoneActors(g.World.GetCurrentArea())
+	gosoh.OnGoToZone = g.World.GoToZone
 
 	g.tick = 0
 
-	return g
+	return g, nil
 }
 
 var currentArea *gosoh.MapArea
 
 func (g *Game) Update() error {
+	if g.Splash != nil {
+		gosoh.SetMusic(gosoh.TitleMusic)
+		g.tick++
+		if g.tick > SplashTicks || len(inpututil.PressedKeys()) > 0 || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			g.Splash = nil
+		}
+		return nil
+	}
+
 	// g.tick++
 	g.Gui.Update()
 	gosoh.ProcessInput()
 	// TODO: Handle AI, randomly move critters around, etc.
 	// ProcessCreatures(g)
 	currentArea = g.World.GetCurrentArea()
+	if gosoh.IsOnDagobah(currentArea) {
+		gosoh.SetMusic(gosoh.DagobahMusic)
+	} else {
+		gosoh.SetMusic(gosoh.OverworldMusic)
+	}
 	gosoh.ProcessMovement(currentArea)
+	gosoh.ProcessScripts(currentArea)
 	g.CenterViewport(currentArea)
 	// if the player has moved, then check loading / unloading Entities
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
+	if g.Splash != nil {
+		g.DrawSplash(screen)
+		return
+	}
+
 	// Draw the Viewport
 	currentArea.DrawLayer(gosoh.TerrainLayer, screen, g.View.X, g.View.Y, g.View.Width, g.View.Height, float64(ElementBuffer))
 	// TODO: Walls and Renderables need to be interleaved and drawn at the same time
@@ -77,9 +161,22 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	gosoh.ShowDebugInfo(screen, g.View.X, g.View.Y)
 	gosoh.DrawEntityBoxes(screen, g.View.X, g.View.Y, float64(ElementBuffer))
 
+	// Dialogs go over everything else in the viewport
+	gosoh.DrawDialog(screen, g.View.X, g.View.Y, g.View.Width, g.View.Height, float64(ElementBuffer))
+
 	g.Gui.Draw(screen)
 }
 
+// Show the startup screen in the middle of the window, as big as it'll fit
+func (g *Game) DrawSplash(screen *ebiten.Image) {
+	w, h := g.Splash.Size()
+	scale := math.Floor(float64(WindowHeight-(2*ElementBuffer)) / float64(h))
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate((float64(WindowWidth)-(float64(w)*scale))/2, (float64(WindowHeight)-(float64(h)*scale))/2)
+	screen.DrawImage(g.Splash, op)
+}
+
 func (g *Game) Layout(w, h int) (int, int) {
 	// 640x360 internal dimensions, by default
 	// 16:9 aspect ratio, with plenty of scaling
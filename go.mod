@@ -6,8 +6,6 @@ require (
 	github.com/blizzy78/ebitenui v0.0.0-20211114161546-ca1a302d930b
 	github.com/bytearena/ecs v1.0.0
 	github.com/davecgh/go-spew v1.1.1
-	github.com/ghostiam/binstruct v1.0.1
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/hajimehoshi/ebiten/v2 v2.2.2
 	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
 )
@@ -31,7 +31,10 @@ func ProcessMovement(a *MapArea) {
 				}
 			}
 			if !tileIsOpen {
-				// TODO: Send "Bump" event
+				if result.Entity.HasComponent(playerComp) {
+					PlaySoundEvent(BumpEvent)
+					QueueScriptEvent(ScriptEvent{Type: BumpTileEvent, X: newX, Y: newY})
+				}
 				crtr.CanMove = true
 				crtr.State = Standing
 			} else {
@@ -52,10 +55,12 @@ func ProcessMovement(a *MapArea) {
 
 			// If we've got less than one nudge left, finish the move
 			if distanceX <= moves.Speed && distanceY <= moves.Speed {
-				// TODO: Send "entered tile" event?
 				pos.X = float64(pos.TileX*TileWidth) + float64(TileWidth/2)
 				pos.Y = float64(pos.TileY*TileHeight) + float64(TileHeight/2)
 				crtr.CanMove = true
+				if result.Entity.HasComponent(playerComp) {
+					QueueScriptEvent(ScriptEvent{Type: WalkTileEvent, X: pos.TileX, Y: pos.TileY})
+				}
 			} else {
 				// If not, nudge the thing closer
 				pos.X += float64(moves.Direction.DeltaX) * moves.Speed
@@ -9,6 +9,7 @@ var playerView *ecs.View
 var moveView *ecs.View
 var drawView *ecs.View
 var collideView *ecs.View
+var actorView *ecs.View
 
 var playerComp *ecs.Component
 var positionComp *ecs.Component
@@ -16,6 +17,8 @@ var renderableComp *ecs.Component
 var creatureComp *ecs.Component
 var movementComp *ecs.Component
 var collideComp *ecs.Component
+var inventoryComp *ecs.Component
+var actorComp *ecs.Component
 
 // Components
 type PlayerInput struct {
@@ -30,10 +33,20 @@ type Creature struct {
 	Facing     CardinalDirection
 	CanMove    bool
 	CreatureId int
+	Health     int
+	WeaponId   int // -1 if unarmed
 }
 
 type PlayerInventory struct {
-	Items []int
+	Items    []int // Tile IDs
+	Selected int   // Index in Items of the one to use next
+}
+
+// Creatures that came from a zone's actor list, so scripts can find them again
+type ZoneActorRef struct {
+	ZoneId int
+	Index  int // In the zone's ZoneActors
+	Hidden bool
 }
 
 type Position struct {
@@ -3,27 +3,30 @@ package gosoh
 import (
 	"fmt"
 
+	"github.com/MasterShizzle/goda-stories/dta"
 	"github.com/bytearena/ecs"
 )
 
 func AddCreature(cInfo CreatureInfo, x, y int) *ecs.Entity {
 	// Add a creature to the entity pool
-	crtr := ECSManager.NewComponent()
-
-	fmt.Printf("[ECSMgr] Adding creature: %s", cInfo.Name)
+	fmt.Printf("[ECSMgr] Adding creature: %s\n", cInfo.Name)
 
 	return ECSManager.NewEntity().
-		AddComponent(crtr, &Creature{
-			Name:   cInfo.Name,
-			State:  Standing,
-			Facing: Down,
+		AddComponent(creatureComp, &Creature{
+			Name:       cInfo.Name,
+			State:      Standing,
+			Facing:     Down,
+			CanMove:    cInfo.Movement != dta.MoveNone && cInfo.Movement != dta.MoveSit,
+			CreatureId: cInfo.Id,
+			Health:     cInfo.Health,
+			WeaponId:   GetCreatureWeapon(cInfo.Id).Id,
 		}).
 		AddComponent(renderableComp, &Renderable{
 			Image: cInfo.Images[Down],
 		}).
 		AddComponent(positionComp, &Position{
-			X:     float64(x*TileWidth) + 0.5, // Spawn in the center of the tile
-			Y:     float64(y*TileHeight) + 0.5,
+			X:     float64(x*TileWidth + (TileWidth / 2)), // Spawn in the center of the tile
+			Y:     float64(y*TileHeight + (TileHeight / 2)),
 			TileX: x,
 			TileY: y,
 		}).
@@ -32,9 +35,75 @@ func AddCreature(cInfo CreatureInfo, x, y int) *ecs.Entity {
 		}).
 		AddComponent(collideComp, &Collidable{
 			IsBlocking: true,
+			LeftEdge:   0.5,
+			RightEdge:  0.5,
+			TopEdge:    0.5,
+			BottomEdge: 0.5,
 		})
 }
 
+// Swap out whatever zone actors there were for the ones in this area
+func SpawnZoneActors(a *MapArea) {
+	for _, result := range actorView.Get() {
+		ECSManager.DisposeEntity(result.Entity)
+	}
+	for zx := range a.Zones {
+		for zy, z := range a.Zones[zx] {
+			if z == nil {
+				continue
+			}
+			for _, act := range z.ZoneActors {
+				cInfo := GetCreatureInfo(act.CreatureId)
+				if cInfo.Id < 0 {
+					continue
+				}
+				AddCreature(cInfo, zx*18+act.ZoneX, zy*18+act.ZoneY).
+					AddComponent(actorComp, &ZoneActorRef{
+						ZoneId: z.Id,
+						Index:  act.Index,
+					})
+			}
+		}
+	}
+}
+
+// Find a zone's actor, if it got spawned
+func findZoneActor(zoneId, index int) *ecs.QueryResult {
+	for _, result := range actorView.Get() {
+		ref := result.Components[actorComp].(*ZoneActorRef)
+		if ref.ZoneId == zoneId && ref.Index == index {
+			return result
+		}
+	}
+	return nil
+}
+
+// Hidden actors aren't drawn and don't get in the way, but they're still there for scripts
+func setActorHidden(result *ecs.QueryResult, hidden bool) {
+	ref := result.Components[actorComp].(*ZoneActorRef)
+	if ref.Hidden == hidden {
+		return
+	}
+	ref.Hidden = hidden
+	crtr := result.Components[creatureComp].(*Creature)
+	if hidden {
+		result.Entity.RemoveComponent(renderableComp)
+		result.Entity.RemoveComponent(collideComp)
+	} else {
+		result.Entity.
+			AddComponent(renderableComp, &Renderable{
+				Image: Creatures[crtr.CreatureId].Images[crtr.Facing],
+			}).
+			AddComponent(collideComp, &Collidable{
+				IsBlocking: true,
+				LeftEdge:   0.5,
+				RightEdge:  0.5,
+				TopEdge:    0.5,
+				BottomEdge: 0.5,
+			})
+	}
+}
+
 func GetCreatureTNum(crtrId int) (tNum int) {
 	if crtrId != Clamp(crtrId, 0, len(Creatures)-1) {
 		return 1680
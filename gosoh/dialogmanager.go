@@ -0,0 +1,127 @@
+package gosoh
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/bitmapfont"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Dialog manager:
+// - show what PlyrSez / CrtrSez say in a speech bubble over whoever's talking
+// - wrap the text, and split it into pages that fit the bubble
+// - one dialog at a time: the rest wait their turn, and nobody moves until they're all gone
+
+const dialogWidth = 400 // Pixels of text per line
+const dialogLines = 4   // Lines per page
+const dialogPadding = 6 // Pixels between the text and the edge of the bubble
+
+var dialogBack = color.RGBA{R: 16, G: 16, B: 40, A: 230}
+var dialogBorder = color.RGBA{R: 220, G: 220, B: 220, A: 255}
+var dialogTextColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+type Dialog struct {
+	Pages  [][]string
+	Page   int
+	Anchor *Position // Who's talking; the bubble follows them around
+	Done   bool      // Dismissed, so any script waiting on it can carry on
+}
+
+// Dialogs waiting to be shown; the first one's the one on screen
+var dialogQueue []*Dialog
+
+// Queue up some text to show over whoever's at anchor
+func ShowDialog(text string, anchor *Position) *Dialog {
+	d := &Dialog{
+		Pages:  paginate(UIFont.Wrap(dialogText(text), dialogWidth), dialogLines),
+		Anchor: anchor,
+	}
+	dialogQueue = append(dialogQueue, d)
+	return d
+}
+
+// Is there a dialog on screen?
+func DialogOpen() bool {
+	return len(dialogQueue) > 0
+}
+
+// Turn the page on a key press or click, or close the dialog after the last one.
+// Returns true if there's a dialog open, in which case nothing else should get the input.
+func ProcessDialog() bool {
+	if !DialogOpen() {
+		return false
+	}
+	d := dialogQueue[0]
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) ||
+		inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		d.Page++
+		if d.Page >= len(d.Pages) {
+			d.Done = true
+			dialogQueue = dialogQueue[1:]
+		}
+	}
+	return true
+}
+
+// Draw the open dialog's bubble above its speaker, kept inside the viewport
+func DrawDialog(screen *ebiten.Image, viewX, viewY, viewWidth, viewHeight, viewOffset float64) {
+	if !DialogOpen() {
+		return
+	}
+	d := dialogQueue[0]
+	lines := d.Pages[d.Page]
+
+	w := float64(dialogWidth + 2*dialogPadding)
+	h := float64(len(lines)*UIFont.LineHeight + 2*dialogPadding)
+	// Centered over the speaker's head, or the middle of the view if there's nobody
+	x := (viewWidth - w) / 2
+	y := (viewHeight - h) / 2
+	if d.Anchor != nil {
+		x = d.Anchor.X - viewX - w/2
+		y = d.Anchor.Y - viewY - float64(TileHeight) - h
+		if y < 0 {
+			// No room above, so go underneath
+			y = d.Anchor.Y - viewY + float64(TileHeight)
+		}
+	}
+	x = ClampFloat(x, 0, viewWidth-w) + viewOffset
+	y = ClampFloat(y, 0, viewHeight-h) + viewOffset
+
+	ebitenutil.DrawRect(screen, x-1, y-1, w+2, h+2, dialogBorder)
+	ebitenutil.DrawRect(screen, x, y, w, h, dialogBack)
+	UIFont.Draw(screen, strings.Join(lines, "\n"), int(x)+dialogPadding, int(y)+dialogPadding, dialogTextColor, bitmapfont.AlignLeft)
+	if d.Page < len(d.Pages)-1 {
+		// More to come
+		UIFont.Draw(screen, "v", int(x+w)-dialogPadding, int(y+h)-UIFont.LineHeight, dialogTextColor, bitmapfont.AlignRight)
+	}
+}
+
+// The game's text is Windows-1252 bytes; they're the same runes as Latin-1, except for the curly quotes.
+// Whatever's not in the UI font after that comes out as a ?
+func dialogText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	r := make([]rune, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case 0x91, 0x92:
+			r = append(r, '\'')
+		case 0x93, 0x94:
+			r = append(r, '"')
+		default:
+			r = append(r, rune(s[i]))
+		}
+	}
+	return string(r)
+}
+
+func paginate(lines []string, perPage int) [][]string {
+	ret := make([][]string, 0)
+	for len(lines) > perPage {
+		ret = append(ret, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	return append(ret, lines)
+}
@@ -2,10 +2,12 @@ package gosoh
 
 import (
 	"fmt"
+	"image/color"
 	"os"
 
+	"github.com/MasterShizzle/goda-stories/bitmapfont"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 func ProcessInput() {
@@ -20,6 +22,37 @@ func ProcessInput() {
 		toggleDebug = true
 	}
 
+	// M mutes, - and = turn the volume down and up
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		ToggleMute()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+		SetVolume(Volume - 0.1)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+		SetVolume(Volume + 0.1)
+	}
+
+	// While there's a dialog up, the keys are for that, and nobody goes anywhere
+	if ProcessDialog() {
+		for _, result := range playerView.Get() {
+			mov := result.Components[movementComp].(*Movable)
+			crtr := result.Components[creatureComp].(*Creature)
+			mov.Direction = NoMove
+			if crtr.CanMove {
+				crtr.State = Standing
+			}
+		}
+		return
+	}
+
+	// Tab picks the next item, and Space uses it on whatever the player's facing
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		SelectNextItem()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		UseSelectedItem()
+	}
+
 	// Without inputs, assume we're standing still
 	dir := NoMove
 
@@ -71,6 +104,8 @@ func ProcessInput() {
 	}
 }
 
+var debugTextColor = color.RGBA{R: 255, G: 255, B: 160, A: 255}
+
 func ShowDebugInfo(screen *ebiten.Image, viewX, viewY float64) {
 	out := ""
 	out += fmt.Sprintf("Viewport: (%0.2f, %0.2f)\n", viewX, viewY)
@@ -83,8 +118,19 @@ func ShowDebugInfo(screen *ebiten.Image, viewX, viewY float64) {
 		out += fmt.Sprintf("State:  %s\nFacing: %s\n", crtr.State, crtr.Facing.Name)
 		out += fmt.Sprintf("CanMove:  %t\n", crtr.CanMove)
 	}
+	out += fmt.Sprintf("Volume: %0.0f%% (Muted: %t)\n", Volume*100, Muted)
+	if item := selectedItem(); item >= 0 {
+		out += fmt.Sprintf("Item: %s (%d of %d)\n", GetItemName(item), playerInventory().Selected+1, len(playerInventory().Items))
+	}
+
+	// Script vars, for the zone the player's in
+	zoneId := -1
+	if scriptZone != nil {
+		zoneId = scriptZone.Id
+	}
+	out += Vars.DebugString(zoneId)
 
-	ebitenutil.DebugPrint(screen, out)
+	UIFont.Draw(screen, out, 2, 2, debugTextColor, bitmapfont.AlignLeft)
 }
 
 func DrawEntityBoxes(screen *ebiten.Image, viewX, viewY, viewOffset float64) {
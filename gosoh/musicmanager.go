@@ -0,0 +1,169 @@
+package gosoh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/midi"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// Music manager:
+// - play the game's MIDI tracks, and switch between them as the game goes on
+
+// Where to find the MIDI files
+var MusicDir = "data/midi"
+
+// Optional .sf2 file to play the music with (-soundfont); the built-in synth is used otherwise
+var SoundFontPath = ""
+
+type MusicState int
+
+const (
+	NoMusic MusicState = iota
+	TitleMusic
+	DagobahMusic
+	OverworldMusic
+	WinMusic // See PlayEndMusic
+	LoseMusic
+)
+
+// Bits of file names we look for, to figure out which track goes with which state
+// If none of them match, that state stays quiet
+var musicTrackNames = map[MusicState][]string{
+	TitleMusic:     {"title", "theme", "intro"},
+	DagobahMusic:   {"dagobah", "yoda", "swamp"},
+	OverworldMusic: {"overworld", "world", "main"},
+	WinMusic:       {"win", "victory"},
+	LoseMusic:      {"lose", "gameover", "death"},
+}
+
+var currentMusic MusicState
+var musicPlayer *audio.Player
+var soundFont *midi.SoundFont
+var soundFontLoaded bool
+var musicEnded bool // Set by PlayEndMusic, so the game doesn't switch back to the overworld track
+
+// For the victory and game over paths: play that track once, and leave it on until ResetMusic
+func PlayEndMusic(won bool) {
+	state := LoseMusic
+	if won {
+		state = WinMusic
+	}
+	SetMusic(state)
+	musicEnded = true
+}
+
+// Let SetMusic change tracks again, for a new game
+func ResetMusic() {
+	musicEnded = false
+}
+
+// Switch to the music for a new state; does nothing if it's already playing
+func SetMusic(state MusicState) {
+	if state == currentMusic || musicEnded {
+		return
+	}
+	currentMusic = state
+	StopMusic()
+	if state == NoMusic {
+		return
+	}
+
+	path, ok := findMusicFile(musicTrackNames[state]...)
+	if !ok {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("[MusicMgr] Couldn't open %s: %v\n", path, err)
+		return
+	}
+	defer f.Close()
+	song, err := midi.Parse(f)
+	if err != nil {
+		fmt.Printf("[MusicMgr] Couldn't read %s: %v\n", path, err)
+		return
+	}
+
+	synth := midi.NewSynth(SampleRate)
+	synth.SoundFont = loadSoundFont()
+	// Winning and losing only play once; everything else loops
+	loop := state != WinMusic && state != LoseMusic
+	musicPlayer, err = audio.NewPlayer(AudioContext(), midi.NewPlayer(song, synth, loop))
+	if err != nil {
+		fmt.Printf("[MusicMgr] Couldn't play %s: %v\n", path, err)
+		musicPlayer = nil
+		return
+	}
+	musicPlayer.SetVolume(Volume)
+	if !Muted {
+		musicPlayer.Play()
+	}
+	fmt.Printf("[MusicMgr] Playing %s\n", filepath.Base(path))
+}
+
+func StopMusic() {
+	if musicPlayer != nil {
+		musicPlayer.Close()
+		musicPlayer = nil
+	}
+}
+
+// Is the player standing in one of Dagobah's zones?
+func IsOnDagobah(a *MapArea) bool {
+	_, _, tx, ty := GetPlayerCoords()
+	z := a.ZoneAt(tx, ty)
+	if z == nil {
+		return false
+	}
+	switch z.Id {
+	case DAGOBAH_BL, DAGOBAH_TL, DAGOBAH_TR, DAGOBAH_BR:
+		return true
+	}
+	return false
+}
+
+// Load the SoundFont the first time it's needed; nil if there isn't one
+func loadSoundFont() *midi.SoundFont {
+	if soundFontLoaded {
+		return soundFont
+	}
+	soundFontLoaded = true
+	if SoundFontPath == "" {
+		return nil
+	}
+
+	f, err := os.Open(SoundFontPath)
+	if err != nil {
+		fmt.Printf("[MusicMgr] Couldn't open SoundFont, using the built-in synth: %v\n", err)
+		return nil
+	}
+	defer f.Close()
+	if soundFont, err = midi.ParseSoundFont(f); err != nil {
+		fmt.Printf("[MusicMgr] Couldn't read SoundFont, using the built-in synth: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[MusicMgr] Loaded SoundFont %s: %d presets\n", soundFont.Name, len(soundFont.Presets))
+	return soundFont
+}
+
+// Find the first MIDI file whose name has any of the given bits in it
+func findMusicFile(names ...string) (string, bool) {
+	entries, err := os.ReadDir(MusicDir)
+	if err != nil {
+		return "", false
+	}
+	for _, name := range names {
+		for _, e := range entries {
+			fName := strings.ToLower(e.Name())
+			ext := filepath.Ext(fName)
+			if (ext == ".mid" || ext == ".midi") && strings.Contains(fName, name) {
+				return filepath.Join(MusicDir, e.Name()), true
+			}
+		}
+	}
+	return "", false
+}
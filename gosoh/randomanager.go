@@ -1,20 +1,22 @@
 package gosoh
 
 import (
-	"crypto/rand"
-	"math/big"
+	"github.com/MasterShizzle/goda-stories/rng"
 )
 
-// Random int from 0 to (X - 1)
-func RandomInt(upperBound int) int {
-	x, _ := rand.Int(rand.Reader, big.NewInt(int64(upperBound)))
-	return int(x.Int64())
-}
+// Random manager:
+// - everything random in gosoh comes out of Random, so the same seed gets the same world back
+// - worldgen, AI and scripts each get their own stream (see the rng package)
+// - Random.Seed is what to show players, so they can share worlds, or replay a bug report
+
+var Random = rng.New(rng.NewSeed())
 
-// Random int from 1 to X
-// RollDie(X) is like rolling an X-sided die
-func RollDie(numSides int) int {
-	return RandomInt(numSides) + 1
+// Start over from the given seed, before making a new world; 0 picks a new one
+func SeedRandom(seed int64) {
+	if seed == 0 {
+		seed = rng.NewSeed()
+	}
+	Random = rng.New(seed)
 }
 
 func Clamp(num, minNum, maxNum int) int {
@@ -0,0 +1,509 @@
+package gosoh
+
+import (
+	"fmt"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+// Script manager:
+// - when something happens in a zone, check its action triggers (IACT)
+// - run the actions of every trigger whose conditions all hold, in order
+// - keep track of the scripts that are waiting, and pick them back up later
+
+type ScriptEventType int
+
+const (
+	EnterZoneEvent ScriptEventType = iota // The player came into a zone
+	BumpTileEvent                         // The player walked into something that doesn't move
+	WalkTileEvent                         // The player finished moving onto a tile
+	UseItemEvent                          // The player used an item on a tile
+)
+
+// Something the scripts might care about; X and Y are tile coords in the MapArea
+type ScriptEvent struct {
+	Type ScriptEventType
+	X    int
+	Y    int
+	Item int // Tile ID of the item, for UseItemEvent
+}
+
+// A trigger partway through its actions
+type runningScript struct {
+	zone    *ZoneInfo
+	originX int // Top left of the zone, in MapArea tiles
+	originY int
+	trigger int
+	next    int     // The next action to run
+	wait    int     // Ticks until it carries on
+	dialog  *Dialog // Or, the dialog it's waiting to be dismissed
+}
+
+var scriptEvents []ScriptEvent
+var runningScripts []*runningScript
+
+// The area and zone the player was in last tick, to spot them moving to another one
+var scriptArea *MapArea
+var scriptZone *ZoneInfo
+
+// Set by the game, to move the player to a whole other zone (GoToZone); x,y are tile coords in that zone.
+// Scripts stop for this tick straight after, and the new MapArea gets picked up on the next one.
+var OnGoToZone func(zoneId, x, y int)
+
+// Forget everything the scripts have done or were doing, for a new game
+func ResetScripts() {
+	Vars = NewVarStore()
+	scriptEvents = nil
+	runningScripts = nil
+	dialogQueue = nil
+	scriptArea = nil
+	scriptZone = nil
+}
+
+// Let the scripts know something happened; they get to it in ProcessScripts
+func QueueScriptEvent(evt ScriptEvent) {
+	scriptEvents = append(scriptEvents, evt)
+}
+
+// Run everything that's due this tick: scripts that were waiting, then whatever the queued events set off
+func ProcessScripts(a *MapArea) {
+	if a != scriptArea {
+		// New area: nothing from the old one applies any more
+		scriptArea = a
+		scriptZone = nil
+		runningScripts = nil
+	}
+
+	// Did the player just come into a zone?
+	_, _, tx, ty := GetPlayerCoords()
+	if z := a.ZoneAt(tx, ty); z != scriptZone {
+		scriptZone = z
+		if z != nil {
+			scriptEvents = append([]ScriptEvent{{Type: EnterZoneEvent, X: tx, Y: ty}}, scriptEvents...)
+		}
+	}
+
+	// Carry on with the ones that were waiting
+	stillRunning := make([]*runningScript, 0, len(runningScripts))
+	for _, s := range runningScripts {
+		if a != scriptArea {
+			break
+		}
+		if s.dialog != nil {
+			if !s.dialog.Done {
+				stillRunning = append(stillRunning, s)
+				continue
+			}
+			s.dialog = nil
+		}
+		if s.wait > 0 {
+			s.wait--
+		}
+		if s.wait == 0 && !runActions(a, s) {
+			continue
+		}
+		stillRunning = append(stillRunning, s)
+	}
+	runningScripts = stillRunning
+	if a != scriptArea {
+		// A script moved us somewhere else
+		return
+	}
+
+	events := scriptEvents
+	scriptEvents = nil
+	for _, evt := range events {
+		zx, zy := evt.X/18, evt.Y/18
+		z := a.ZoneAt(evt.X, evt.Y)
+		if z == nil {
+			continue
+		}
+		state := Vars.Zone(z.Id)
+		firstTime := evt.Type == EnterZoneEvent && !state.Visited
+		for tIdx := range z.ActionTriggers {
+			if state.Disabled[tIdx] || isRunning(z, tIdx) {
+				continue
+			}
+			if !checkConditions(a, z, zx*18, zy*18, tIdx, evt, firstTime) {
+				continue
+			}
+			s := &runningScript{zone: z, originX: zx * 18, originY: zy * 18, trigger: tIdx}
+			if runActions(a, s) {
+				runningScripts = append(runningScripts, s)
+			}
+			if a != scriptArea {
+				// A script moved us somewhere else, so the rest of this zone's triggers are moot
+				return
+			}
+		}
+		if evt.Type == EnterZoneEvent {
+			state.Visited = true
+		}
+	}
+}
+
+func isRunning(z *ZoneInfo, tIdx int) bool {
+	for _, s := range runningScripts {
+		if s.zone == z && s.trigger == tIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// Amounts and the numbers vars get compared with are int16s (AddGVar 65535 counts down by one);
+// tiles, items and coords are unsigned
+func signedArg(v int) int {
+	return int(int16(v))
+}
+
+// Do all of a trigger's conditions hold for this event?
+func checkConditions(a *MapArea, z *ZoneInfo, ox, oy, tIdx int, evt ScriptEvent, firstTime bool) bool {
+	// Event coords, within the zone
+	ex, ey := evt.X-ox, evt.Y-oy
+	for _, c := range z.ActionTriggers[tIdx].Conditions {
+		args := padArgs(c.Args, 6)
+		value := signedArg(args[0]) // What the var conditions compare against
+		ok := false
+		switch c.Condition {
+		case dta.FirstEnter:
+			ok = firstTime
+		case dta.Enter:
+			ok = evt.Type == EnterZoneEvent
+		case dta.BumpTile:
+			ok = evt.Type == BumpTileEvent && ex == args[0] && ey == args[1] && a.tileIn(ox+args[0], oy+args[1], 1) == args[2]
+		case dta.Walk:
+			ok = evt.Type == WalkTileEvent && ex == args[0] && ey == args[1] && a.tileIn(ox+args[0], oy+args[1], 0) == args[2]
+		case dta.UseItem:
+			ok = evt.Type == UseItemEvent && ex == args[0] && ey == args[1] &&
+				a.tileIn(ox+args[0], oy+args[1], args[2]) == args[3] && evt.Item == args[4]
+		case dta.UseWrongItem:
+			ok = evt.Type == UseItemEvent && ex == args[0] && ey == args[1] && evt.Item != args[4]
+		case dta.TempVarEq:
+			ok = Vars.TempVar(z.Id) == value
+		case dta.TempVarNe:
+			ok = Vars.TempVar(z.Id) != value
+		case dta.RandVarEq:
+			ok = Vars.Random == value
+		case dta.RandVarNe:
+			ok = Vars.Random != value
+		case dta.RandVarGt:
+			ok = Vars.Random > value
+		case dta.RandVarLt:
+			ok = Vars.Random < value
+		case dta.GlobalVarEq:
+			ok = Vars.Global == value
+		case dta.GlobalVarNe:
+			ok = Vars.Global != value
+		case dta.GlobalVarGt:
+			ok = Vars.Global > value
+		case dta.GlobalVarLt:
+			ok = Vars.Global < value
+		case dta.CheckTile:
+			ok = a.tileIn(ox+args[1], oy+args[2], args[3]) == args[0]
+		case dta.CheckTileVar:
+			ok = Vars.TileVar(TileVarKey{z.Id, args[1], args[2], args[3]}) == value
+		case dta.EnemyDead:
+			ok = isActorDead(z.Id, args[0])
+		case dta.AllEnemiesDead:
+			ok = true
+			for _, act := range z.ZoneActors {
+				ok = ok && isActorDead(z.Id, act.Index)
+			}
+		case dta.HasItem:
+			ok = playerHasItem(args[0])
+		case dta.HealthLt:
+			ok = playerHealth() < value
+		case dta.HealthGt:
+			ok = playerHealth() > value
+		case dta.PlayerAtPos:
+			_, _, tx, ty := GetPlayerCoords()
+			ok = tx-ox == args[0] && ty-oy == args[1]
+		case dta.ExperienceEq:
+			ok = Vars.GamesWon == value
+		case dta.ExperienceGt:
+			ok = Vars.GamesWon > value
+		case dta.GameInProgress:
+			ok = true
+		case dta.GameCompleted, dta.EnterVehicle, dta.CheckQuestItem1, dta.CheckQuestItem2:
+			// TODO: needs worldgen to hand out the quest items, and vehicles
+			ok = false
+		default:
+			// Nobody knows what these check, so they never pass
+			ok = false
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Run a script's actions until it finishes (false) or has to wait (true)
+func runActions(a *MapArea, s *runningScript) bool {
+	actions := s.zone.ActionTriggers[s.trigger].Actions
+	for s.next < len(actions) {
+		act := actions[s.next]
+		args := padArgs(act.Args, 5)
+		x, y := s.originX+args[0], s.originY+args[1]
+		s.next++
+
+		switch act.Action {
+		case dta.SetTile:
+			a.setTileIn(x, y, args[2], args[3])
+		case dta.ClearTile:
+			a.setTileIn(x, y, args[2], 65535)
+		case dta.MoveTile:
+			tNum := a.tileIn(x, y, args[2])
+			a.setTileIn(x, y, args[2], 65535)
+			a.setTileIn(s.originX+args[3], s.originY+args[4], args[2], tNum)
+		case dta.DrawOverlayTile:
+			a.setTileIn(x, y, 2, args[2])
+		case dta.PlayerSay:
+			s.dialog = ShowDialog(act.Text, playerPosition())
+			return true
+		case dta.CreatureSay:
+			s.dialog = ShowDialog(act.Text, creaturePositionAt(x, y))
+			return true
+		case dta.RedrawTile, dta.RedrawRect, dta.RenderChanges:
+			// Everything gets redrawn every frame anyway
+		case dta.WaitTicks:
+			if args[0] > 0 {
+				s.wait = args[0]
+				return true
+			}
+		case dta.PlaySound:
+			PlaySound(args[0])
+		case dta.RandomNum:
+			if args[0] > 0 {
+				Vars.Random = Random.Scripts.Roll(args[0])
+			}
+		case dta.SetTempVar:
+			Vars.SetTempVar(s.zone.Id, signedArg(args[0]))
+		case dta.AddTempVar:
+			Vars.AddTempVar(s.zone.Id, signedArg(args[0]))
+		case dta.SetTileVar:
+			Vars.SetTileVar(TileVarKey{s.zone.Id, args[0], args[1], args[2]}, signedArg(args[3]))
+		case dta.SetPlayerPos:
+			SetPlayerTile(a, x, y)
+		case dta.RunOnlyOnce:
+			Vars.Zone(s.zone.Id).Disabled[s.trigger] = true
+		case dta.ShowObject, dta.HideObject:
+			if args[0] < len(s.zone.Hotspots) {
+				enabled := 0
+				if act.Action == dta.ShowObject {
+					enabled = 1
+				}
+				Vars.SetHotspotEnabled(s.zone.Id, args[0], enabled)
+			}
+		case dta.ShowEntity, dta.HideEntity:
+			if result := findZoneActor(s.zone.Id, args[0]); result != nil {
+				setActorHidden(result, act.Action == dta.HideEntity)
+			}
+		case dta.ShowAllEntities, dta.HideAllEntities:
+			for _, result := range actorView.Get() {
+				if result.Components[actorComp].(*ZoneActorRef).ZoneId == s.zone.Id {
+					setActorHidden(result, act.Action == dta.HideAllEntities)
+				}
+			}
+		case dta.SpawnItem:
+			// TODO: let the player pick it up
+			a.setTileIn(s.originX+args[1], s.originY+args[2], 1, args[0])
+		case dta.GiveToPlayer:
+			givePlayerItem(args[0])
+		case dta.TakeFromPlayer:
+			takePlayerItem(args[0])
+		case dta.GoToZone:
+			if OnGoToZone != nil && args[0] < len(Zones) {
+				OnGoToZone(args[0], args[1], args[2])
+				scriptArea = nil
+			}
+			// We're somewhere else now, so the rest of this script doesn't make sense
+			return false
+		case dta.SetGlobalVar:
+			Vars.Global = signedArg(args[0])
+		case dta.AddGlobalVar:
+			Vars.Global += signedArg(args[0])
+		case dta.SetRandVar:
+			Vars.Random = signedArg(args[0])
+		case dta.AddToHealth:
+			addPlayerHealth(signedArg(args[0]))
+		case dta.FadeIn, dta.ReleaseCamera, dta.LockCamera, dta.MoveCamera, dta.OpenOrShow:
+			// TODO: camera and screen effects
+		default:
+			fmt.Printf("[ScriptMgr] Zone %03d script %d: skipping %s\n", s.zone.Id, s.trigger, act.Action.ToString())
+		}
+	}
+	return false
+}
+
+// Scripts from Tiled or by hand might leave off the unused args at the end
+func padArgs(args []int, n int) []int {
+	if len(args) >= n {
+		return args
+	}
+	ret := make([]int, n)
+	copy(ret, args)
+	return ret
+}
+
+// The tile ID on one layer (0 Terrain, 1 Walls, 2 Overlay); -1 if that's off the map
+func (a *MapArea) tileIn(x, y, layer int) int {
+	if x < 0 || y < 0 || x >= len(a.Tiles) || y >= len(a.Tiles[x]) {
+		return -1
+	}
+	switch layer {
+	case 0:
+		return a.Tiles[x][y].TerrainTileId
+	case 1:
+		return a.Tiles[x][y].WallTileId
+	case 2:
+		return a.Tiles[x][y].OverlayTileId
+	}
+	return -1
+}
+
+func (a *MapArea) setTileIn(x, y, layer, tNum int) {
+	if x < 0 || y < 0 || x >= len(a.Tiles) || y >= len(a.Tiles[x]) {
+		return
+	}
+	t := &a.Tiles[x][y]
+	switch layer {
+	case 0:
+		t.TerrainTileId = tNum
+	case 1:
+		t.WallTileId = tNum
+		t.IsWalkable = CheckIsWalkable(tNum)
+	case 2:
+		t.OverlayTileId = tNum
+	}
+}
+
+// Put the player on a tile, straight away
+func SetPlayerTile(a *MapArea, x, y int) {
+	if x < 0 || y < 0 || x >= len(a.Tiles) || y >= len(a.Tiles[x]) {
+		return
+	}
+	for _, result := range playerView.Get() {
+		pos := result.Components[positionComp].(*Position)
+		crtr := result.Components[creatureComp].(*Creature)
+		pos.TileX, pos.TileY = x, y
+		pos.X = float64(x*TileWidth + (TileWidth / 2))
+		pos.Y = float64(y*TileHeight + (TileHeight / 2))
+		crtr.State = Standing
+		crtr.CanMove = true
+	}
+}
+
+func playerPosition() *Position {
+	for _, result := range playerView.Get() {
+		return result.Components[positionComp].(*Position)
+	}
+	return nil
+}
+
+// Whoever's standing on that tile, or failing that, the tile itself
+func creaturePositionAt(x, y int) *Position {
+	for _, result := range drawView.Get() {
+		pos := result.Components[positionComp].(*Position)
+		if pos.TileX == x && pos.TileY == y {
+			return pos
+		}
+	}
+	return &Position{
+		X:     float64(x*TileWidth + (TileWidth / 2)),
+		Y:     float64(y*TileHeight + (TileHeight / 2)),
+		TileX: x,
+		TileY: y,
+	}
+}
+
+func isActorDead(zoneId, index int) bool {
+	result := findZoneActor(zoneId, index)
+	return result == nil || result.Components[creatureComp].(*Creature).Health <= 0
+}
+
+func playerInventory() *PlayerInventory {
+	for _, result := range playerView.Get() {
+		if inv, ok := result.Entity.GetComponentData(inventoryComp); ok {
+			return inv.(*PlayerInventory)
+		}
+	}
+	return &PlayerInventory{}
+}
+
+func playerHasItem(tNum int) bool {
+	for _, i := range playerInventory().Items {
+		if i == tNum {
+			return true
+		}
+	}
+	return false
+}
+
+func givePlayerItem(tNum int) {
+	inv := playerInventory()
+	inv.Items = append(inv.Items, tNum)
+	PlaySoundEvent(PickupEvent)
+}
+
+// The item that Space uses, or -1 if the player's got nothing
+func selectedItem() int {
+	inv := playerInventory()
+	if len(inv.Items) == 0 {
+		return -1
+	}
+	inv.Selected = Clamp(inv.Selected, 0, len(inv.Items)-1)
+	return inv.Items[inv.Selected]
+}
+
+func SelectNextItem() {
+	inv := playerInventory()
+	if len(inv.Items) > 0 {
+		inv.Selected = (inv.Selected + 1) % len(inv.Items)
+	}
+}
+
+// Use the selected item on the tile the player's facing, for UseItem and WrongItem scripts
+func UseSelectedItem() {
+	item := selectedItem()
+	if item < 0 {
+		return
+	}
+	for _, result := range playerView.Get() {
+		pos := result.Components[positionComp].(*Position)
+		crtr := result.Components[creatureComp].(*Creature)
+		if !crtr.CanMove {
+			// Not partway through a step
+			return
+		}
+		QueueScriptEvent(ScriptEvent{Type: UseItemEvent, X: pos.TileX + crtr.Facing.DeltaX, Y: pos.TileY + crtr.Facing.DeltaY, Item: item})
+	}
+}
+
+func takePlayerItem(tNum int) {
+	inv := playerInventory()
+	for i, item := range inv.Items {
+		if item == tNum {
+			inv.Items = append(inv.Items[:i], inv.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+func playerHealth() int {
+	for _, result := range playerView.Get() {
+		return result.Components[creatureComp].(*Creature).Health
+	}
+	return 0
+}
+
+func addPlayerHealth(amount int) {
+	for _, result := range playerView.Get() {
+		crtr := result.Components[creatureComp].(*Creature)
+		crtr.Health += amount
+		if crtr.Health < 0 {
+			crtr.Health = 0
+		}
+	}
+}
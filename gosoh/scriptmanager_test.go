@@ -0,0 +1,31 @@
+package gosoh
+
+import (
+	"testing"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+func TestNegativeValues(t *testing.T) {
+	ResetScripts()
+	z := &ZoneInfo{Id: 5}
+	z.ActionTriggers = []ActionTrigger{
+		// AddGVar -1, which is 65535 in the data file
+		{Actions: []TriggerAction{{Action: dta.AddGlobalVar, Args: []int{65535, 0, 0, 0, 0}}}},
+		// GVar_LT 0 and GVar_EQ -1
+		{Conditions: []TriggerCondition{
+			{Condition: dta.GlobalVarLt, Args: []int{0, 0, 0, 0, 0, 0}},
+			{Condition: dta.GlobalVarEq, Args: []int{65535, 0, 0, 0, 0, 0}},
+		}},
+	}
+
+	if runActions(nil, &runningScript{zone: z, trigger: 0}) {
+		t.Fatal("AddGVar shouldn't wait")
+	}
+	if Vars.Global != -1 {
+		t.Errorf("global var is %d after AddGVar -1, want -1", Vars.Global)
+	}
+	if !checkConditions(nil, z, 0, 0, 1, ScriptEvent{}, false) {
+		t.Errorf("GVar_LT 0 and GVar_EQ -1 should hold with the global var at -1")
+	}
+}
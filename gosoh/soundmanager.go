@@ -0,0 +1,213 @@
+package gosoh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+// Sound manager:
+// - load up the WAVs listed in SNDS, and play them when stuff happens
+
+// Where to find the sound effects (-sfx)
+var SfxDir = "data/sfx"
+
+// Global volume, from 0 (silent) to 1 (full blast)
+var Volume = 1.0
+var Muted = false
+
+const SampleRate = 44100
+
+type SoundEvent int
+
+const (
+	BumpEvent SoundEvent = iota
+	PickupEvent
+	HitEvent // Nothing gets hit yet, but combat will play it
+	NumSoundEvents
+)
+
+// The SNDS index to play for each event (-eventsounds); -1 keeps it quiet.
+// Events that aren't in here get guessed from eventSoundNames the first time they happen.
+var EventSounds = map[SoundEvent]int{}
+
+// Bits of file names we look for, when an event's sound hasn't been set
+// If none of them match, that event stays quiet
+var eventSoundNames = map[SoundEvent][]string{
+	BumpEvent:   {"bump", "push"},
+	PickupEvent: {"pickup", "getitem", "item"},
+	HitEvent:    {"hit", "hurt", "ouch"},
+}
+
+func (e SoundEvent) ToString() string {
+	switch e {
+	case BumpEvent:
+		return "bump"
+	case PickupEvent:
+		return "pickup"
+	case HitEvent:
+		return "hit"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(e))
+}
+
+var audioContext *audio.Context
+var sfxFiles map[string]string       // Lowercase file name => path, so we don't care how they're capitalized
+var soundCache map[int][]byte        // Decoded sounds, by SNDS index; nil means we couldn't load it
+var sfxPlayers map[int]*audio.Player // The last player for each sound, so they don't pile up
+
+// There can only be one audio context, so everything that makes noise should share it
+func AudioContext() *audio.Context {
+	if audioContext == nil {
+		audioContext = audio.NewContext(SampleRate)
+	}
+	return audioContext
+}
+
+// Play the sound at the given SNDS index
+func PlaySound(sndId int) {
+	if Muted {
+		return
+	}
+	pcm := loadSound(sndId)
+	if pcm == nil {
+		return
+	}
+
+	// If this sound is still going, don't start it again
+	if p, ok := sfxPlayers[sndId]; ok {
+		if p.IsPlaying() {
+			return
+		}
+		p.Close()
+	}
+
+	p := audio.NewPlayerFromBytes(AudioContext(), pcm)
+	p.SetVolume(Volume)
+	p.Play()
+	sfxPlayers[sndId] = p
+}
+
+// Play whatever sound goes with an engine event
+func PlaySoundEvent(evt SoundEvent) {
+	sndId, ok := EventSounds[evt]
+	if !ok {
+		sndId = FindSound(eventSoundNames[evt]...)
+		EventSounds[evt] = sndId
+		fmt.Printf("[SoundMgr] No sound set for %s, guessed %d\n", evt.ToString(), sndId)
+	}
+	if sndId >= 0 {
+		PlaySound(sndId)
+	}
+}
+
+// Find the first sound whose file name has any of the given bits in it; -1 if none do
+func FindSound(names ...string) int {
+	for _, name := range names {
+		for sndId, s := range Sounds {
+			if strings.Contains(strings.ToLower(soundFileName(s)), strings.ToLower(name)) {
+				return sndId
+			}
+		}
+	}
+	return -1
+}
+
+func SetVolume(v float64) {
+	Volume = ClampFloat(v, 0, 1)
+	for _, p := range sfxPlayers {
+		p.SetVolume(Volume)
+	}
+	if musicPlayer != nil {
+		musicPlayer.SetVolume(Volume)
+	}
+}
+
+func ToggleMute() {
+	Muted = !Muted
+	if Muted {
+		for _, p := range sfxPlayers {
+			p.Pause()
+		}
+	}
+	if musicPlayer != nil && Muted {
+		musicPlayer.Pause()
+	} else if musicPlayer != nil {
+		musicPlayer.Play()
+	}
+}
+
+// SNDS has Windows-y paths like "sfx\\blah.wav"; we just want the file
+func soundFileName(s string) string {
+	s = strings.ReplaceAll(s, "\\", "/")
+	return filepath.Base(s)
+}
+
+// Load and decode a sound, or grab it from the cache if we've done that already
+func loadSound(sndId int) []byte {
+	if soundCache == nil {
+		soundCache = make(map[int][]byte)
+		sfxPlayers = make(map[int]*audio.Player)
+	}
+	if pcm, ok := soundCache[sndId]; ok {
+		return pcm
+	}
+	// Whatever happens, don't try this one again
+	soundCache[sndId] = nil
+
+	if sndId != Clamp(sndId, 0, len(Sounds)-1) {
+		fmt.Printf("[SoundMgr] No such sound: %d\n", sndId)
+		return nil
+	}
+	name := soundFileName(Sounds[sndId])
+	if !strings.EqualFold(filepath.Ext(name), ".wav") {
+		// Probably music, which isn't handled here
+		return nil
+	}
+	path, ok := findSfxFile(name)
+	if !ok {
+		fmt.Printf("[SoundMgr] Missing sound file: %s\n", name)
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("[SoundMgr] Couldn't open %s: %v\n", path, err)
+		return nil
+	}
+	defer f.Close()
+	s, err := wav.DecodeWithSampleRate(SampleRate, f)
+	if err != nil {
+		fmt.Printf("[SoundMgr] Couldn't decode %s: %v\n", path, err)
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	if _, err := io.Copy(buf, s); err != nil {
+		fmt.Printf("[SoundMgr] Couldn't decode %s: %v\n", path, err)
+		return nil
+	}
+
+	soundCache[sndId] = buf.Bytes()
+	return soundCache[sndId]
+}
+
+func findSfxFile(name string) (string, bool) {
+	if sfxFiles == nil {
+		sfxFiles = make(map[string]string)
+		entries, err := os.ReadDir(SfxDir)
+		if err != nil {
+			fmt.Printf("[SoundMgr] Can't read the sfx folder: %v\n", err)
+		}
+		for _, e := range entries {
+			sfxFiles[strings.ToLower(e.Name())] = filepath.Join(SfxDir, e.Name())
+		}
+	}
+	path, ok := sfxFiles[strings.ToLower(name)]
+	return path, ok
+}
@@ -0,0 +1,179 @@
+package gosoh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Variable store:
+// - everything the scripts remember, in one place, so it can go in a save game
+// - TVar: one counter per zone
+// - tile vars: one per spot (zone, X, Y, layer)
+// - GVar: one for the whole world
+// - RVar: the random register, set by RVarRange and SetRVar
+// - which hotspots ShowObj / HideObj have turned on or off, so the zone data itself never changes
+
+// The scripts' variables; ResetScripts starts them over for a new game
+var Vars = NewVarStore()
+
+type VarStore struct {
+	Zones    map[int]*ZoneVars
+	Tiles    map[TileVarKey]int
+	Global   int
+	Random   int
+	GamesWon int // For Wins_EQ / Wins_GT; there's only ever this game, so far
+}
+
+// What the scripts remember about each zone
+type ZoneVars struct {
+	Visited  bool
+	Counter  int          // The zone's temp var (TVar)
+	Disabled map[int]bool // Triggers that have hit RunOnce
+	Hotspots map[int]int  // Enabled, for the hotspots that ShowObj / HideObj changed, by index
+}
+
+// A tile var lives at a spot in a zone; layer is 0 for Terrain, 1 for Walls and 2 for Overlay
+type TileVarKey struct {
+	ZoneId int
+	X      int
+	Y      int
+	Layer  int
+}
+
+func NewVarStore() *VarStore {
+	return &VarStore{
+		Zones: make(map[int]*ZoneVars),
+		Tiles: make(map[TileVarKey]int),
+	}
+}
+
+// A zone's vars, made on first use
+func (v *VarStore) Zone(zoneId int) *ZoneVars {
+	zv, ok := v.Zones[zoneId]
+	if !ok {
+		zv = &ZoneVars{Disabled: make(map[int]bool), Hotspots: make(map[int]int)}
+		v.Zones[zoneId] = zv
+	}
+	return zv
+}
+
+func (v *VarStore) TempVar(zoneId int) int {
+	if zv, ok := v.Zones[zoneId]; ok {
+		return zv.Counter
+	}
+	return 0
+}
+
+func (v *VarStore) SetTempVar(zoneId, value int) {
+	v.Zone(zoneId).Counter = value
+}
+
+func (v *VarStore) AddTempVar(zoneId, amount int) {
+	v.Zone(zoneId).Counter += amount
+}
+
+// Unset tile vars are 0
+func (v *VarStore) TileVar(k TileVarKey) int {
+	return v.Tiles[k]
+}
+
+func (v *VarStore) SetTileVar(k TileVarKey, value int) {
+	if value == 0 {
+		delete(v.Tiles, k)
+		return
+	}
+	v.Tiles[k] = value
+}
+
+// Whether a zone's hotspot is turned on (1) or off (0): what a script set it to, or else what the zone started with
+func (v *VarStore) HotspotEnabled(z *ZoneInfo, index int) int {
+	if zv, ok := v.Zones[z.Id]; ok {
+		if enabled, ok := zv.Hotspots[index]; ok {
+			return enabled
+		}
+	}
+	if index < 0 || index >= len(z.Hotspots) {
+		return 0
+	}
+	return z.Hotspots[index].Enabled
+}
+
+func (v *VarStore) SetHotspotEnabled(zoneId, index, enabled int) {
+	v.Zone(zoneId).Hotspots[index] = enabled
+}
+
+// Write the whole store out as JSON, for a save game
+func (v *VarStore) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(v)
+}
+
+// Read back a store written by Save
+func LoadVarStore(r io.Reader) (*VarStore, error) {
+	v := NewVarStore()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return nil, fmt.Errorf("loading vars: %w", err)
+	}
+	for _, zv := range v.Zones {
+		if zv.Disabled == nil {
+			zv.Disabled = make(map[int]bool)
+		}
+		if zv.Hotspots == nil {
+			zv.Hotspots = make(map[int]int)
+		}
+	}
+	return v, nil
+}
+
+// JSON map keys have to be strings, so tile vars are keyed like "93:4,7,1"
+func (k TileVarKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d:%d,%d,%d", k.ZoneId, k.X, k.Y, k.Layer)), nil
+}
+
+func (k *TileVarKey) UnmarshalText(b []byte) error {
+	if _, err := fmt.Sscanf(string(b), "%d:%d,%d,%d", &k.ZoneId, &k.X, &k.Y, &k.Layer); err != nil {
+		return fmt.Errorf("bad tile var key %q: %w", b, err)
+	}
+	return nil
+}
+
+// For the debug overlay: the world vars, then the given zone's
+func (v *VarStore) DebugString(zoneId int) string {
+	out := fmt.Sprintf("GVar: %d  RVar: %d\n", v.Global, v.Random)
+	if zoneId < 0 {
+		return out
+	}
+	out += fmt.Sprintf("Zone %03d TVar: %d", zoneId, v.TempVar(zoneId))
+	if zv, ok := v.Zones[zoneId]; ok && len(zv.Disabled) > 0 {
+		out += fmt.Sprintf("  RunOnce done: %d", len(zv.Disabled))
+	}
+	if zv, ok := v.Zones[zoneId]; ok && len(zv.Hotspots) > 0 {
+		out += fmt.Sprintf("  Hotspots changed: %d", len(zv.Hotspots))
+	}
+	out += "\n"
+
+	// Tile vars, in a steady order so they don't jump around
+	keys := make([]TileVarKey, 0)
+	for k := range v.Tiles {
+		if k.ZoneId == zoneId {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Layer < b.Layer
+	})
+	for _, k := range keys {
+		out += fmt.Sprintf("  Tile (%d, %d) layer %d: %d\n", k.X, k.Y, k.Layer, v.Tiles[k])
+	}
+	return out
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"image"
 
+	"github.com/MasterShizzle/goda-stories/dta"
+	"github.com/MasterShizzle/goda-stories/maprender"
+	"github.com/MasterShizzle/goda-stories/worldgen"
 	"github.com/bytearena/ecs"
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -50,6 +53,8 @@ func InitializeECS() {
 	movementComp = ECSManager.NewComponent()
 	positionComp = ECSManager.NewComponent()
 	collideComp = ECSManager.NewComponent()
+	inventoryComp = ECSManager.NewComponent()
+	actorComp = ECSManager.NewComponent()
 
 	// Add the Player Entity
 	// TODO: actually try to place the player on a movable tile
@@ -70,6 +75,8 @@ func InitializeECS() {
 			Facing:     Down,
 			CanMove:    true,
 			CreatureId: 0,
+			Health:     Creatures[0].Health,
+			WeaponId:   GetCreatureWeapon(0).Id,
 		}).
 		AddComponent(renderableComp, &Renderable{
 			Image: Creatures[0].Images[Down], // TODO: test the walking animations
@@ -90,6 +97,9 @@ func InitializeECS() {
 			RightEdge:  0.3,
 			TopEdge:    0.2,
 			BottomEdge: 0.5,
+		}).
+		AddComponent(inventoryComp, &PlayerInventory{
+			Items: make([]int, 0),
 		})
 
 	players := ecs.BuildTag(playerComp, renderableComp, movementComp, creatureComp, positionComp)
@@ -110,11 +120,20 @@ func InitializeECS() {
 	collidables := ecs.BuildTag(collideComp, positionComp)
 	ECSTags["collidables"] = collidables
 	collideView = ECSManager.CreateView(collidables)
+
+	actors := ecs.BuildTag(actorComp, creatureComp, positionComp)
+	ECSTags["actors"] = actors
+	actorView = ECSManager.CreateView(actors)
 }
 
-// Worldgen!
-func NewOverworld(w, h int) *MapArea {
-	// Make Dagobah
+// Dagobah, where the game starts; Indy just gets the first zone, for now
+func NewDagobah() *MapArea {
+	if CurrentGame == dta.Indy {
+		start := NewMapArea(1, 1)
+		start.AddZoneToArea(0, 0, 0)
+		return &start
+	}
+
 	dago := NewMapArea(2, 2)
 
 	dago.AddZoneToArea(DAGOBAH_BL, 0, 1)
@@ -125,6 +144,57 @@ func NewOverworld(w, h int) *MapArea {
 	return &dago
 }
 
+// Worldgen! Lay out a planet (see the worldgen package), and hand back its puzzle chain too.
+// 0 PuzzleSteps gets the usual number for its size.
+func NewOverworld(opts PlanetOptions) (*MapArea, *PuzzleChain, error) {
+	if CurrentGame == dta.Indy {
+		// Indy's zone types aren't mapped yet, so there's nothing to build a world out of
+		return nil, nil, fmt.Errorf("%w: no worldgen for Indy yet", worldgen.ErrNoZones)
+	}
+
+	if opts.PuzzleSteps == 0 {
+		opts.PuzzleSteps = worldgen.DefaultSteps(opts.Width, opts.Height)
+	}
+	planet, err := worldgen.Generate(Zones, Puzzles, opts, &Random.Worldgen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, h := planet.Width, planet.Height
+	a := NewMapArea(w, h)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			if planet.Zones[x][y] >= 0 {
+				a.AddZoneToArea(planet.Zones[x][y], x, y)
+			} else {
+				a.clearZone(x, y)
+			}
+		}
+	}
+	fmt.Printf("[NewOverworld] Made a %dx%d %s planet, landing at (%d,%d)\n", w, h, planet.Biome, planet.StartX, planet.StartY)
+	return &a, &planet.Chain, nil
+}
+
+// Nothing at this spot: it's the edge of the world, so nothing gets drawn and nobody gets in
+func (a *MapArea) clearZone(x, y int) {
+	for j := 0; j < 18; j++ {
+		for i := 0; i < 18; i++ {
+			tx, ty := x*18+i, y*18+j
+			a.Tiles[tx][ty] = MapTile{
+				TerrainTileId: 65535,
+				WallTileId:    65535,
+				OverlayTileId: 65535,
+				Box: CollisionBox{
+					X:      float64(tx * TileWidth),
+					Y:      float64(ty * TileHeight),
+					Width:  float64(TileWidth),
+					Height: float64(TileHeight),
+				},
+			}
+		}
+	}
+}
+
 func NewMapArea(w, h int) MapArea {
 	ret := MapArea{
 		Width:  w,
@@ -181,7 +251,7 @@ func (a *MapArea) AddZoneToArea(zoneId, x, y int) {
 	// Copy the zone tile info onto this area's tiles, and set the collision box position
 	for j := 0; j < zInfo.Height; j++ {
 		for i := 0; i < zInfo.Width; i++ {
-			t := zInfo.GetTileAt(i, j)
+			t := GetZoneTile(&zInfo, i, j)
 			tx := (x * zInfo.Width) + i
 			ty := (y * zInfo.Height) + j
 			t.Box.X = float64(tx * TileWidth)
@@ -193,8 +263,17 @@ func (a *MapArea) AddZoneToArea(zoneId, x, y int) {
 	fmt.Printf("[AddZoneToArea] Added zone %03d to MapArea starting at (%d,%d)\n", zoneId, x*18, y*18)
 }
 
+// Which zone is at the given tile coords; nil if there isn't one
+func (a *MapArea) ZoneAt(tx, ty int) *ZoneInfo {
+	x, y := tx/18, ty/18
+	if tx < 0 || ty < 0 || x >= len(a.Zones) || y >= len(a.Zones[x]) {
+		return nil
+	}
+	return a.Zones[x][y]
+}
+
 // Pass in X,Y coords => get the Tile info at those coords
-func (z *ZoneInfo) GetTileAt(x, y int) MapTile {
+func GetZoneTile(z *ZoneInfo, x, y int) MapTile {
 	tIndex := (z.Width * y) + x
 	ret := MapTile{}
 	ret.TerrainTileId = z.TileMaps.Terrain[tIndex]
@@ -246,11 +325,46 @@ func (a *MapArea) DrawLayer(lyr LayerName, screen *ebiten.Image, viewX, viewY, v
 
 func (a *MapArea) PrintMap() {
 	fmt.Printf("Map of MapArea %d:\n", a.Id)
-	for y := 0; y < len(a.Zones); y++ {
+	for y := 0; y < a.Height; y++ {
 		line1 := ""
-		for x := 0; x < len(a.Zones[y]); x++ {
+		for x := 0; x < a.Width; x++ {
+			if a.Zones[x][y] == nil {
+				line1 += "---  "
+				continue
+			}
 			line1 += fmt.Sprintf("%03d  ", a.Zones[x][y].Id)
 		}
 		fmt.Println(line1)
 	}
 }
+
+// Draw the whole area to one image, without needing the ebiten tileset; see maprender for the overlays.
+// This is what's on the map right now, so it includes anything scripts have changed since the zones got added,
+// hotspots they've turned on or off included.
+func (a *MapArea) RenderImage(opts maprender.Options) *image.NRGBA {
+	ra := maprender.NewArea(a.Width, a.Height)
+	for x := range a.Zones {
+		copy(ra.Zones[x], a.Zones[x])
+		for _, z := range a.Zones[x] {
+			if z == nil {
+				continue
+			}
+			enabled := make([]int, len(z.Hotspots))
+			for i := range z.Hotspots {
+				enabled[i] = Vars.HotspotEnabled(z, i)
+			}
+			ra.HotspotEnabled[z.Id] = enabled
+		}
+	}
+	for x := range a.Tiles {
+		for y, t := range a.Tiles[x] {
+			ra.Tiles[x][y] = maprender.Tile{
+				Terrain:  t.TerrainTileId,
+				Walls:    t.WallTileId,
+				Overlay:  t.OverlayTileId,
+				Walkable: t.IsWalkable,
+			}
+		}
+	}
+	return maprender.Render(ra, TileInfos, Creatures, opts)
+}
@@ -0,0 +1,321 @@
+package iact
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+var (
+	ErrSyntax        = errors.New("syntax error")
+	ErrUnknownOpcode = errors.New("unknown opcode")
+	ErrArgCount      = errors.New("wrong number of args")
+	ErrBadArg        = errors.New("bad arg")
+	ErrTileRange     = errors.New("tile ID out of range")
+)
+
+// AsmError says where in the script something's wrong; Line and Col count from 1
+type AsmError struct {
+	Line int
+	Col  int
+	Err  error
+}
+
+func (e *AsmError) Error() string {
+	return fmt.Sprintf("iact: %d:%d: %v", e.Line, e.Col, e.Err)
+}
+
+func (e *AsmError) Unwrap() error {
+	return e.Err
+}
+
+// Everything that was wrong with a script, in order
+type ErrorList []*AsmError
+
+func (l ErrorList) Error() string {
+	lines := make([]string, len(l))
+	for i, e := range l {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type token struct {
+	text string
+	col  int
+}
+
+// Turn a script (as written by Disassemble, or by hand) back into action triggers.
+// Tile and item args have to be below tileCount, unless it's 0. Any problems come back
+// as an ErrorList, with every one that was found.
+func Assemble(r io.Reader, tileCount int) ([]dta.ActionTrigger, error) {
+	ret := make([]dta.ActionTrigger, 0)
+	errs := make(ErrorList, 0)
+	fail := func(line, col int, err error, format string, a ...interface{}) {
+		errs = append(errs, &AsmError{Line: line, Col: col, Err: fmt.Errorf("%w: "+format, append([]interface{}{err}, a...)...)})
+	}
+
+	var trg *dta.ActionTrigger
+	scriptLine := 0
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		toks, err := tokenize(scanner.Text())
+		if err != nil {
+			errs = append(errs, &AsmError{Line: lineNum, Col: err.col, Err: fmt.Errorf("%w: %s", ErrSyntax, err.text)})
+			continue
+		}
+		if len(toks) == 0 {
+			continue
+		}
+
+		kw := toks[0]
+		switch kw.text {
+		case "script":
+			if trg != nil {
+				fail(lineNum, kw.col, ErrSyntax, "script inside the script from line %d; missing end?", scriptLine)
+			}
+			if len(toks) > 2 {
+				fail(lineNum, toks[2].col, ErrSyntax, "script only takes a number")
+			} else if len(toks) == 2 {
+				if _, err := strconv.Atoi(toks[1].text); err != nil {
+					fail(lineNum, toks[1].col, ErrSyntax, "script number %q isn't a number", toks[1].text)
+				}
+			}
+			ret = append(ret, dta.ActionTrigger{
+				Conditions: make([]dta.TriggerCondition, 0),
+				Actions:    make([]dta.TriggerAction, 0),
+			})
+			trg = &ret[len(ret)-1]
+			scriptLine = lineNum
+		case "end":
+			if trg == nil {
+				fail(lineNum, kw.col, ErrSyntax, "end without a script")
+			} else if len(toks) > 1 {
+				fail(lineNum, toks[1].col, ErrSyntax, "nothing goes after end")
+			}
+			trg = nil
+		case "when", "do":
+			if trg == nil {
+				fail(lineNum, kw.col, ErrSyntax, "%s outside of a script", kw.text)
+				continue
+			}
+			if len(toks) < 2 {
+				fail(lineNum, kw.col+len(kw.text), ErrSyntax, "%s needs an opcode", kw.text)
+				continue
+			}
+			op := toks[1]
+			if kw.text == "when" {
+				cType, ok := dta.ConditionTypeByName(op.text)
+				if !ok {
+					fail(lineNum, op.col, ErrUnknownOpcode, "no condition called %q", op.text)
+					continue
+				}
+				argNames, known := conditionArgs[cType]
+				args, text, lineErrs := parseArgs(lineNum, op.text, toks[2:], argNames, known, ConditionArgs, tileCount)
+				errs = append(errs, lineErrs...)
+				if text != nil {
+					fail(lineNum, text.col, ErrSyntax, "conditions don't have text")
+				}
+				trg.Conditions = append(trg.Conditions, dta.TriggerCondition{Condition: cType, Args: args})
+			} else {
+				aType, ok := dta.ActionTypeByName(op.text)
+				if !ok {
+					fail(lineNum, op.col, ErrUnknownOpcode, "no action called %q", op.text)
+					continue
+				}
+				argNames, known := actionArgs[aType]
+				args, text, lineErrs := parseArgs(lineNum, op.text, toks[2:], argNames, known, ActionArgs, tileCount)
+				errs = append(errs, lineErrs...)
+				actn := dta.TriggerAction{Action: aType, Args: args}
+				if text != nil {
+					s, err := unquoteText(text.text)
+					if err != nil {
+						fail(lineNum, text.col, ErrBadArg, "text: %v", err)
+					}
+					actn.Text = s
+				}
+				trg.Actions = append(trg.Actions, actn)
+			}
+		default:
+			fail(lineNum, kw.col, ErrSyntax, "expected script, when, do or end, not %q", kw.text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if trg != nil {
+		fail(scriptLine, 1, ErrSyntax, "script never ends")
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ret, nil
+}
+
+// Fill in the arg slots from name=value (or plain, positional) args; the quoted text token, if any, comes back on its own
+func parseArgs(lineNum int, op string, toks []token, argNames []string, known bool, slots int, tileCount int) ([]int, *token, ErrorList) {
+	args := make([]int, slots)
+	set := make([]bool, slots)
+	var text *token
+	errs := make(ErrorList, 0)
+	fail := func(col int, err error, format string, a ...interface{}) {
+		errs = append(errs, &AsmError{Line: lineNum, Col: col, Err: fmt.Errorf("%w: "+format, append([]interface{}{err}, a...)...)})
+	}
+
+	next := 0
+	for i := range toks {
+		tok := toks[i]
+		if strings.HasPrefix(tok.text, `"`) {
+			if text != nil {
+				fail(tok.col, ErrSyntax, "only one text per line")
+			}
+			text = &toks[i]
+			continue
+		}
+
+		name, value := "", tok.text
+		slot := -1
+		if eq := strings.Index(tok.text, "="); eq >= 0 {
+			name, value = tok.text[:eq], tok.text[eq+1:]
+			slot = slotFor(name, argNames, known)
+			if slot < 0 || slot >= slots {
+				fail(tok.col, ErrBadArg, "%s has no arg called %q", op, name)
+				continue
+			}
+		} else {
+			// Positional args fill the slots in order
+			slot = next
+			if slot >= slots {
+				fail(tok.col, ErrArgCount, "%s takes at most %d args", op, slots)
+				continue
+			}
+			if known && slot < len(argNames) {
+				name = argNames[slot]
+			}
+		}
+		next = slot + 1
+		if set[slot] {
+			fail(tok.col, ErrBadArg, "arg %d given twice", slot)
+			continue
+		}
+
+		v, err := parseValue(value)
+		if err != nil {
+			fail(tok.col+len(tok.text)-len(value), ErrBadArg, "%v", err)
+			continue
+		}
+		if isTileArg(name) && tileCount > 0 && v != noValue && v >= tileCount {
+			fail(tok.col+len(tok.text)-len(value), ErrTileRange, "%s=%d, but there are only %d tiles", name, v, tileCount)
+		}
+		args[slot] = v
+		set[slot] = true
+	}
+
+	// Every named arg has to be there
+	if known {
+		missing := make([]string, 0)
+		for i, name := range argNames {
+			if !set[i] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			col := 1
+			if len(toks) > 0 {
+				col = toks[len(toks)-1].col
+			}
+			fail(col, ErrArgCount, "%s takes %d args (%s), missing %s", op, len(argNames), strings.Join(argNames, ", "), strings.Join(missing, ", "))
+		}
+	}
+	return args, text, errs
+}
+
+// Which slot a name=value arg goes in: its name for known opcodes, or argN for any slot
+func slotFor(name string, argNames []string, known bool) int {
+	if known {
+		for i, n := range argNames {
+			if n == name {
+				return i
+			}
+		}
+	}
+	if strings.HasPrefix(name, "arg") {
+		if n, err := strconv.Atoi(name[3:]); err == nil && n >= 0 && (!known || n >= len(argNames)) {
+			return n
+		}
+	}
+	return -1
+}
+
+func parseValue(s string) (int, error) {
+	if s == "none" {
+		return noValue, nil
+	}
+	n, err := strconv.ParseInt(s, 0, 32)
+	if err != nil || n < -32768 || n > noValue {
+		return 0, fmt.Errorf("%q should be a number from -32768 to 65535, or none", s)
+	}
+	// Negative numbers are stored the way the game reads them, as an int16
+	return int(uint16(n)), nil
+}
+
+// Undo quoteText
+func unquoteText(s string) (string, error) {
+	u, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("can't read %s", s)
+	}
+	b := make([]byte, 0, len(u))
+	for _, r := range u {
+		if r > 0xFF {
+			return "", fmt.Errorf("%q can't be shown by the game", r)
+		}
+		b = append(b, byte(r))
+	}
+	return string(b), nil
+}
+
+// Split a line into words and quoted strings, dropping any comment.
+// On an error, the token has what went wrong and where.
+func tokenize(line string) ([]token, *token) {
+	ret := make([]token, 0)
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			return ret, nil
+		case c == '"':
+			start := i
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(line) {
+				return nil, &token{text: "text is missing its closing quote", col: start + 1}
+			}
+			i++
+			ret = append(ret, token{text: line[start:i], col: start + 1})
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' && line[i] != '#' && line[i] != '"' {
+				i++
+			}
+			ret = append(ret, token{text: line[start:i], col: start + 1})
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+package iact
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+func TestRoundTrip(t *testing.T) {
+	z := &dta.ZoneInfo{Id: 93, Biome: "swamp", Type: "Town"}
+	z.ActionTriggers = []dta.ActionTrigger{
+		{
+			Conditions: []dta.TriggerCondition{
+				{Condition: dta.BumpTile, Args: []int{4, 7, 812, 0, 0, 0}},
+				// Nobody knows what these do, so every slot has to survive as argN
+				{Condition: dta.Unknown10, Args: []int{1, 0, 65535, 0, 0, 9}},
+			},
+			Actions: []dta.TriggerAction{
+				{Action: dta.PlayerSay, Args: []int{0, 0, 0, 0, 0}, Text: "Hmm, a \"detonator\".\r\nBetter not touch it."},
+				{Action: dta.SetTile, Args: []int{4, 7, 1, 65535, 0}},
+				{Action: dta.Unknown1f, Args: []int{0, 3, 0, 0, 0x32c}},
+			},
+		},
+		{
+			Conditions: []dta.TriggerCondition{},
+			Actions: []dta.TriggerAction{
+				// The game's text is Latin-1 (well, cp1252), one byte per character
+				{Action: dta.CreatureSay, Args: []int{2, 3, 0, 0, 0}, Text: "Caf\xe9 \x92open\x92 \\ \x00\xff"},
+				{Action: dta.RunOnlyOnce, Args: []int{0, 0, 0, 0, 0}},
+			},
+		},
+		{
+			// Values are signed, so these come out as -2 and -1
+			Conditions: []dta.TriggerCondition{{Condition: dta.GlobalVarLt, Args: []int{65534, 0, 0, 0, 0, 0}}},
+			Actions:    []dta.TriggerAction{{Action: dta.AddGlobalVar, Args: []int{65535, 0, 0, 0, 0}}},
+		},
+	}
+
+	var script bytes.Buffer
+	if err := Disassemble(&script, z, nil); err != nil {
+		t.Fatal(err)
+	}
+	text := script.String()
+	if !strings.Contains(text, "do AddGVar value=-1") {
+		t.Errorf("AddGVar 65535 should be written as -1:\n%s", text)
+	}
+	got, err := Assemble(&script, 0)
+	if err != nil {
+		t.Fatalf("assembling:\n%s\n%v", text, err)
+	}
+	if !reflect.DeepEqual(got, z.ActionTriggers) {
+		t.Errorf("round trip changed the triggers:\n got %+v\nwant %+v", got, z.ActionTriggers)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		err    error
+		line   int
+		col    int
+	}{
+		{"not a keyword", "script 0\n    wehn BumpTile 1 2 3\nend\n", ErrSyntax, 2, 5},
+		{"missing quote", "script 0\n    do PlyrSez \"oops\nend\n", ErrSyntax, 2, 16},
+		{"never ends", "\nscript 0\n    do RunOnce\n", ErrSyntax, 2, 1},
+		{"unknown condition", "script 0\n    when BumpTyle 1 2 3\nend\n", ErrUnknownOpcode, 2, 10},
+		{"unknown action", "script 0\n  do SetTyle x=4 y=7 layer=1 tile=none\nend\n", ErrUnknownOpcode, 2, 6},
+		{"missing arg", "script 0\n    when BumpTile x=1 y=2\nend\n", ErrArgCount, 2, 23},
+		{"too many args", "script 0\n    do RunOnce 1 2 3 4 5 6\nend\n", ErrArgCount, 2, 26},
+		{"no such arg", "script 0\n    do WaitFor tick=3\nend\n", ErrBadArg, 2, 16},
+		{"bad value", "script 0\n    do WaitFor ticks=lots\nend\n", ErrBadArg, 2, 22},
+		{"value too small", "script 0\n    do AddGVar value=-40000\nend\n", ErrBadArg, 2, 22},
+		{"bad text", "script 0\n    do PlyrSez \"\\u4e16\"\nend\n", ErrBadArg, 2, 16},
+		{"tile out of range", "script 0\n    when BumpTile x=4 y=7 tile=5000\nend\n", ErrTileRange, 2, 32},
+	}
+	for _, tt := range tests {
+		_, err := Assemble(strings.NewReader(tt.script), 2123)
+		var list ErrorList
+		if !errors.As(err, &list) || len(list) == 0 {
+			t.Errorf("%s: got %v, want an ErrorList", tt.name, err)
+			continue
+		}
+		e := list[0]
+		if !errors.Is(e, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, e, tt.err)
+		}
+		if e.Line != tt.line || e.Col != tt.col {
+			t.Errorf("%s: error at %d:%d, want %d:%d (%v)", tt.name, e.Line, e.Col, tt.line, tt.col, e)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package iact
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+// Looks up what to call a tile ID, for the comments; "" leaves it out
+type NameFunc func(tNum int) string
+
+// Write a zone's action triggers out as a script, one block per trigger.
+// names can be nil, if there's nothing to look tile names up in.
+func Disassemble(w io.Writer, z *dta.ZoneInfo, names NameFunc) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Zone %03d", z.Id)
+	if desc := strings.TrimSpace(z.Biome + " " + z.Type); desc != "" {
+		fmt.Fprintf(bw, " (%s)", desc)
+	}
+	fmt.Fprintf(bw, ": %d scripts\n", len(z.ActionTriggers))
+
+	for i, trg := range z.ActionTriggers {
+		fmt.Fprintf(bw, "\nscript %d\n", i)
+		for _, c := range trg.Conditions {
+			argNames, known := conditionArgs[c.Condition]
+			writeLine(bw, "when", c.Condition.ToString(), argNames, known, c.Args, nil, names)
+			if !known {
+				fmt.Fprintf(bw, "  # unknown condition 0x%02x", byte(c.Condition))
+			}
+			bw.WriteString("\n")
+		}
+		for _, a := range trg.Actions {
+			argNames, known := actionArgs[a.Action]
+			text := &a.Text
+			// Only the talking actions are expected to have text
+			if a.Text == "" && a.Action != dta.PlayerSay && a.Action != dta.CreatureSay {
+				text = nil
+			}
+			writeLine(bw, "do", a.Action.ToString(), argNames, known, a.Args, text, names)
+			if !known {
+				fmt.Fprintf(bw, "  # unknown action 0x%02x", byte(a.Action))
+			}
+			bw.WriteString("\n")
+		}
+		bw.WriteString("end\n")
+	}
+	return bw.Flush()
+}
+
+// One "when" or "do" line, without the newline.
+// Named args always get written; other slots only if they're not 0, as argN.
+func writeLine(bw *bufio.Writer, keyword, op string, argNames []string, known bool, args []int, text *string, names NameFunc) {
+	fmt.Fprintf(bw, "    %s %s", keyword, op)
+	comments := make([]string, 0)
+	for i, v := range args {
+		name := ""
+		if known && i < len(argNames) {
+			name = argNames[i]
+		} else if v == 0 {
+			continue
+		} else {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		fmt.Fprintf(bw, " %s=%s", name, formatValue(name, v))
+		if names != nil && isTileArg(name) && v != noValue {
+			if n := names(v); n != "" {
+				comments = append(comments, n)
+			}
+		}
+	}
+	if text != nil {
+		bw.WriteString(" " + quoteText(*text))
+	}
+	if len(comments) > 0 {
+		bw.WriteString("  # " + strings.Join(comments, ", "))
+	}
+}
+
+// Amounts and the numbers vars get compared with are signed (AddGVar value=-1 counts down);
+// tiles, items and coordinates aren't
+func formatValue(name string, v int) string {
+	if isSignedArg(name) {
+		return strconv.Itoa(int(int16(v)))
+	}
+	if v == noValue {
+		return "none"
+	}
+	return strconv.Itoa(v)
+}
+
+// Text is single bytes; each one becomes the rune with the same number, so accents stay readable
+func quoteText(s string) string {
+	r := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		r[i] = rune(s[i])
+	}
+	return strconv.Quote(string(r))
+}
@@ -0,0 +1,99 @@
+// Package iact turns zone action triggers (IACT) into a readable script language and back.
+// See docs/Scripts.md for what the language looks like.
+package iact
+
+import (
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+// Every condition has 6 arg slots, and every action 5 (plus its text)
+const ConditionArgs = 6
+const ActionArgs = 5
+
+// 0xFFFF is "no tile" / "no item" etc.; scripts spell it none
+const noValue = 65535
+
+// What each arg slot means, in order; slots past the end are unused.
+// Opcodes missing from these maps are ones nobody's worked out yet.
+var conditionArgs = map[dta.TriggerConditionType][]string{
+	dta.FirstEnter:      {},
+	dta.Enter:           {},
+	dta.BumpTile:        {"x", "y", "tile"},
+	dta.UseItem:         {"x", "y", "layer", "tile", "item"},
+	dta.Walk:            {"x", "y", "tile"},
+	dta.TempVarEq:       {"value"},
+	dta.RandVarEq:       {"value"},
+	dta.RandVarGt:       {"value"},
+	dta.RandVarLt:       {"value"},
+	dta.EnterVehicle:    {},
+	dta.CheckTile:       {"tile", "x", "y", "layer"},
+	dta.EnemyDead:       {"actor"},
+	dta.AllEnemiesDead:  {},
+	dta.HasItem:         {"item"},
+	dta.CheckQuestItem1: {"item"},
+	dta.CheckQuestItem2: {"item"},
+	dta.GameInProgress:  {},
+	dta.GameCompleted:   {},
+	dta.HealthLt:        {"value"},
+	dta.HealthGt:        {"value"},
+	dta.UseWrongItem:    {"x", "y", "layer", "tile", "item"},
+	dta.PlayerAtPos:     {"x", "y"},
+	dta.GlobalVarEq:     {"value"},
+	dta.GlobalVarLt:     {"value"},
+	dta.GlobalVarGt:     {"value"},
+	dta.ExperienceEq:    {"value"},
+	dta.TempVarNe:       {"value"},
+	dta.RandVarNe:       {"value"},
+	dta.GlobalVarNe:     {"value"},
+	dta.CheckTileVar:    {"value", "x", "y", "layer"},
+	dta.ExperienceGt:    {"value"},
+}
+
+var actionArgs = map[dta.TriggerActionType][]string{
+	dta.SetTile:         {"x", "y", "layer", "tile"},
+	dta.ClearTile:       {"x", "y", "layer"},
+	dta.MoveTile:        {"x", "y", "layer", "toX", "toY"},
+	dta.DrawOverlayTile: {"x", "y", "tile"},
+	dta.PlayerSay:       {},
+	dta.CreatureSay:     {"x", "y"},
+	dta.RedrawTile:      {"x", "y"},
+	dta.RedrawRect:      {"x", "y", "width", "height"},
+	dta.RenderChanges:   {},
+	dta.WaitTicks:       {"ticks"},
+	dta.PlaySound:       {"sound"},
+	dta.FadeIn:          {},
+	dta.RandomNum:       {"max"},
+	dta.SetTempVar:      {"value"},
+	dta.AddTempVar:      {"value"},
+	dta.SetTileVar:      {"x", "y", "layer", "value"},
+	dta.ReleaseCamera:   {},
+	dta.LockCamera:      {},
+	dta.SetPlayerPos:    {"x", "y"},
+	dta.MoveCamera:      {"x", "y", "toX", "toY", "speed"},
+	dta.RunOnlyOnce:     {},
+	dta.ShowObject:      {"hotspot"},
+	dta.HideObject:      {"hotspot"},
+	dta.ShowEntity:      {"actor"},
+	dta.HideEntity:      {"actor"},
+	dta.ShowAllEntities: {},
+	dta.HideAllEntities: {},
+	dta.SpawnItem:       {"item", "x", "y"},
+	dta.GiveToPlayer:    {"item"},
+	dta.TakeFromPlayer:  {"item"},
+	dta.OpenOrShow:      {},
+	dta.GoToZone:        {"zone", "x", "y"},
+	dta.SetGlobalVar:    {"value"},
+	dta.AddGlobalVar:    {"value"},
+	dta.SetRandVar:      {"value"},
+	dta.AddToHealth:     {"value"},
+}
+
+// Args the game reads as an int16
+func isSignedArg(name string) bool {
+	return name == "value"
+}
+
+// Args that hold a tile ID (items are tiles too)
+func isTileArg(name string) bool {
+	return name == "tile" || name == "item"
+}
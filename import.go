@@ -0,0 +1,328 @@
+package main
+
+// Read Tiled maps (as written by saveZoneToTiledMap) back into zones, so they can be edited or made from scratch
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+	"github.com/MasterShizzle/goda-stories/gosoh"
+)
+
+// Tiled keeps tile flips in the top bits of each gid
+const tiledFlipBits = 0xE0000000
+
+var zoneFileName = regexp.MustCompile(`zone_(\d+)\.tmx$`)
+
+// Load a zone from a .tmx file. Maps without an Id property take it from a zone_NNN.tmx
+// file name, or get -1, which addTiledZone treats as a brand-new zone.
+func loadTiledMap(fileName string) (gosoh.ZoneInfo, error) {
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return gosoh.ZoneInfo{}, err
+	}
+	m := TiledXMLMap{}
+	if err := xml.Unmarshal(b, &m); err != nil {
+		return gosoh.ZoneInfo{}, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	defaultId := -1
+	if match := zoneFileName.FindStringSubmatch(fileName); match != nil {
+		defaultId, _ = strconv.Atoi(match[1])
+	}
+	z, err := zoneFromTiledMap(m, defaultId)
+	if err != nil {
+		return z, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return z, nil
+}
+
+func zoneFromTiledMap(m TiledXMLMap, defaultId int) (gosoh.ZoneInfo, error) {
+	z := gosoh.ZoneInfo{
+		Id:            defaultId,
+		Width:         m.Width,
+		Height:        m.Height,
+		SharedCounter: 65535,
+	}
+
+	// Map properties
+	props := tiledProperties(m.Properties)
+	z.Biome = props["Biome"]
+	z.Type = props["Type"]
+	z.IsOverworld = props["IsOverworld"] == "true"
+	z.Izx4b = props["Izx4b"]
+	for name, field := range map[string]*int{
+		"Id":            &z.Id,
+		"TypeId":        &z.TypeId,
+		"BiomeId":       &z.BiomeId,
+		"Planet":        &z.Planet,
+		"IzonSize":      &z.IzonSize,
+		"SharedCounter": &z.SharedCounter,
+		"IzaxUnknown":   &z.IzaxUnknown,
+		"Izx4a":         &z.Izx4a,
+	} {
+		if v, ok := props[name]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return z, fmt.Errorf("map property %s: %w", name, err)
+			}
+			*field = n
+		}
+	}
+
+	// Tile layers
+	for _, layer := range m.Layers {
+		var dst *[]int
+		switch layer.Name {
+		case "Terrain":
+			dst = &z.TileMaps.Terrain
+		case "Walls":
+			dst = &z.TileMaps.Walls
+		case "Overlay":
+			dst = &z.TileMaps.Overlay
+		default:
+			continue
+		}
+		tiles, err := parseLayerCSV(layer, m.Tileset.FirstGid)
+		if err != nil {
+			return z, fmt.Errorf("layer %s: %w", layer.Name, err)
+		}
+		if len(tiles) != z.Width*z.Height {
+			return z, fmt.Errorf("layer %s has %d tiles, want %dx%d", layer.Name, len(tiles), z.Width, z.Height)
+		}
+		*dst = tiles
+	}
+	// Layers that aren't there are empty
+	for _, dst := range []*[]int{&z.TileMaps.Terrain, &z.TileMaps.Walls, &z.TileMaps.Overlay} {
+		if *dst == nil {
+			*dst = make([]int, z.Width*z.Height)
+			for i := range *dst {
+				(*dst)[i] = 65535
+			}
+		}
+	}
+
+	// Object groups
+	z.Hotspots = make([]gosoh.ZoneHotspot, 0)
+	z.ZoneActors = make([]gosoh.ZoneActor, 0)
+	z.QuestNPCs = make([]int, 0)
+	z.RewardItems = make([]int, 0)
+	z.ActionTriggers = make([]gosoh.ActionTrigger, 0)
+	for _, group := range m.Objects {
+		for _, obj := range group.Objects {
+			props := tiledProperties(obj.Properties)
+			switch group.Name {
+			case "Hotspots":
+				hs := gosoh.ZoneHotspot{
+					Id:   len(z.Hotspots),
+					Type: dta.TriggerHotspotType(obj.Type),
+					X:    tileCoord(obj.X, gosoh.TileWidth),
+					Y:    tileCoord(obj.Y, gosoh.TileHeight),
+				}
+				var err error
+				if hs.Enabled, err = intProperty(props, "Enabled", 1); err != nil {
+					return z, fmt.Errorf("hotspot %d: %w", hs.Id, err)
+				}
+				if hs.Arg, err = intProperty(props, "Arg", 65535); err != nil {
+					return z, fmt.Errorf("hotspot %d: %w", hs.Id, err)
+				}
+				z.Hotspots = append(z.Hotspots, hs)
+			case "ZoneActors":
+				act := gosoh.ZoneActor{
+					Index:      len(z.ZoneActors),
+					CreatureId: obj.Type,
+					ZoneX:      tileCoord(obj.X, gosoh.TileWidth),
+					ZoneY:      tileCoord(obj.Y, gosoh.TileHeight),
+					Args:       make([]byte, 6),
+				}
+				if v, ok := props["Args"]; ok {
+					args, err := hex.DecodeString(v)
+					if err != nil || len(args) != 6 {
+						return z, fmt.Errorf("actor %d: Args should be 6 bytes of hex, not %q", act.Index, v)
+					}
+					act.Args = args
+				}
+				if v := props["Unknown"]; v != "" {
+					unknown, err := hex.DecodeString(v)
+					if err != nil || len(unknown) != 32 {
+						return z, fmt.Errorf("actor %d: Unknown should be 32 bytes of hex, not %q", act.Index, v)
+					}
+					act.Unknown = unknown
+				}
+				z.ZoneActors = append(z.ZoneActors, act)
+			case "QuestNPCs":
+				z.QuestNPCs = append(z.QuestNPCs, obj.Type)
+			case "Rewards":
+				z.RewardItems = append(z.RewardItems, obj.Type)
+			case "ActionTriggers":
+				trg, err := triggerFromProperties(obj.Properties)
+				if err != nil {
+					return z, fmt.Errorf("trigger %q: %w", obj.Name, err)
+				}
+				z.ActionTriggers = append(z.ActionTriggers, trg)
+			}
+		}
+	}
+
+	return z, nil
+}
+
+// The IF_nnn properties are the conditions, and THEN_nnn the actions, each in order
+func triggerFromProperties(props []TiledXMLProperty) (gosoh.ActionTrigger, error) {
+	sorted := make([]TiledXMLProperty, len(props))
+	copy(sorted, props)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	trg := gosoh.ActionTrigger{
+		Conditions: make([]gosoh.TriggerCondition, 0),
+		Actions:    make([]gosoh.TriggerAction, 0),
+	}
+	for _, p := range sorted {
+		switch {
+		case strings.HasPrefix(p.Name, "IF_"):
+			// Name,arg,arg...
+			fields := strings.Split(p.Value, ",")
+			cType, ok := dta.ConditionTypeByName(fields[0])
+			if !ok {
+				return trg, fmt.Errorf("%s: unknown condition %q", p.Name, fields[0])
+			}
+			args, err := parseArgs(fields[1:])
+			if err != nil {
+				return trg, fmt.Errorf("%s: %w", p.Name, err)
+			}
+			trg.Conditions = append(trg.Conditions, gosoh.TriggerCondition{Condition: cType, Args: args})
+		case strings.HasPrefix(p.Name, "THEN_"):
+			// Name, then the 5 args, then the text (which can have commas of its own)
+			fields := strings.SplitN(utf8ToLatin1(p.Value), ",", 7)
+			aType, ok := dta.ActionTypeByName(fields[0])
+			if !ok {
+				return trg, fmt.Errorf("%s: unknown action %q", p.Name, fields[0])
+			}
+			if len(fields) != 7 {
+				return trg, fmt.Errorf("%s: want 5 args and the text, got %q", p.Name, p.Value)
+			}
+			args, err := parseArgs(fields[1:6])
+			if err != nil {
+				return trg, fmt.Errorf("%s: %w", p.Name, err)
+			}
+			trg.Actions = append(trg.Actions, gosoh.TriggerAction{Action: aType, Args: args, Text: fields[6]})
+		}
+	}
+	return trg, nil
+}
+
+func parseArgs(fields []string) ([]int, error) {
+	ret := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, fmt.Errorf("arg %d: %w", i, err)
+		}
+		ret[i] = n
+	}
+	return ret, nil
+}
+
+// Undo latin1ToUTF8
+func utf8ToLatin1(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			r = '?'
+		}
+		b = append(b, byte(r))
+	}
+	return string(b)
+}
+
+// Tile IDs from a CSV layer; gid 0 (no tile) is 65535, like in the data file
+func parseLayerCSV(layer TiledXMLMapLayer, firstGid int) ([]int, error) {
+	if layer.TileData.Encoding != "csv" {
+		return nil, fmt.Errorf("only CSV layers are supported, not %q", layer.TileData.Encoding)
+	}
+	if firstGid == 0 {
+		firstGid = 1
+	}
+	ret := make([]int, 0)
+	for _, f := range strings.Split(layer.TileData.Body, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		gid, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		gid &^= tiledFlipBits
+		if gid == 0 {
+			ret = append(ret, 65535)
+		} else {
+			ret = append(ret, int(gid)-firstGid)
+		}
+	}
+	return ret, nil
+}
+
+func tiledProperties(props []TiledXMLProperty) map[string]string {
+	ret := make(map[string]string)
+	for _, p := range props {
+		ret[p.Name] = p.Value
+	}
+	return ret
+}
+
+func intProperty(props map[string]string, name string, fallback int) (int, error) {
+	v, ok := props[name]
+	if !ok {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("property %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// Load every .tmx in a folder into the zone list: zones with an Id replace the original,
+// and new ones (no Id, or past the end) get added on the end with the next free Id
+func loadTiledZones(dir string, zones []gosoh.ZoneInfo) ([]gosoh.ZoneInfo, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmx"))
+	if err != nil {
+		return zones, err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		z, err := loadTiledMap(f)
+		if err != nil {
+			return zones, err
+		}
+		var id int
+		zones, id = addTiledZone(zones, z)
+		fmt.Printf("[loadTiledZones] Loaded zone %d from %s\n", id, f)
+	}
+	return zones, nil
+}
+
+func addTiledZone(zones []gosoh.ZoneInfo, z gosoh.ZoneInfo) ([]gosoh.ZoneInfo, int) {
+	if z.Id >= 0 && z.Id < len(zones) {
+		zones[z.Id] = z
+		return zones, z.Id
+	}
+	z.Id = len(zones)
+	return append(zones, z), z.Id
+}
+
+// Which tile an object's in, from its position in pixels
+func tileCoord(px float64, tileSize int) int {
+	return int(math.Floor(px / float64(tileSize)))
+}
@@ -0,0 +1,284 @@
+package main
+
+// Installer: pull the game's files straight off the original CD image
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+	"github.com/MasterShizzle/goda-stories/iso"
+)
+
+// Where the hashes of everything we installed get written, in sha256sum's format
+const checksumFile = "CHECKSUMS"
+
+func runInstall(args []string) {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	dest := fs.String("dest", "data", "folder to install the game files into")
+	knownFile := fs.String("known", "", "known-good hashes of the original release, in sha256sum's format (like a CHECKSUMS from a good install), to check the disc against")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s install [-dest data] [-known sums] <game CD image.iso>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	isoFile, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer isoFile.Close()
+	img, err := iso.Open(isoFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[Install] Opened %s\n", fs.Arg(0))
+
+	// This repo doesn't ship the original release's hashes, since nobody's checked them against a
+	// known-good disc yet, so without -known we can only check that each copy matches the disc
+	var known map[string]string
+	if *knownFile != "" {
+		if known, err = loadChecksums(*knownFile); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		fmt.Println("[Install] No -known hashes, so the disc itself can't be checked; only the copies are")
+	}
+	unknown := 0
+	check := func(rel, sum string) {
+		if known == nil {
+			return
+		}
+		want, ok := known[strings.ToLower(rel)]
+		if !ok {
+			fmt.Printf("[Install] No known-good hash for %s\n", rel)
+			unknown++
+			return
+		}
+		if want != sum {
+			log.Fatalf("[Install] %s doesn't match the original release: it should be %s, but the disc has %s", rel, want, sum)
+		}
+	}
+
+	sums := make(map[string]string)
+
+	// The data file first, since it tells us which sounds we need
+	var dataName string
+	var dataFile iso.File
+	for _, name := range dataFiles {
+		if dataFile, err = img.Find(name); err == nil {
+			dataName = name
+			break
+		}
+	}
+	if dataName == "" {
+		log.Fatalf("[Install] No data file on the disc: looked for %s", strings.Join(dataFiles, " and "))
+	}
+	if sums[dataName], err = installFile(img, dataFile, filepath.Join(*dest, dataName)); err != nil {
+		log.Fatal(err)
+	}
+	check(dataName, sums[dataName])
+
+	// Make sure it's actually usable
+	f, err := os.Open(filepath.Join(*dest, dataName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := dta.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("[Install] %s doesn't look right: %v", dataFile.Path, err)
+	}
+	fmt.Printf("[Install] %s is %s, version %s\n", dataFile.Path, data.Game.ToString(), data.VersionString())
+
+	// Sound effects: whatever SNDS lists, under the names it lists them as
+	missing := 0
+	for _, s := range data.Sounds {
+		name := path.Base(strings.ReplaceAll(s, "\\", "/"))
+		if !strings.EqualFold(path.Ext(name), ".wav") {
+			continue
+		}
+		sfx, err := img.Find(name)
+		if err != nil {
+			fmt.Printf("[Install] Not on the disc: %s\n", name)
+			missing++
+			continue
+		}
+		rel := path.Join("sfx", name)
+		if sums[rel], err = installFile(img, sfx, filepath.Join(*dest, rel)); err != nil {
+			log.Fatal(err)
+		}
+		check(rel, sums[rel])
+	}
+
+	// Music: every MIDI file on the disc
+	var songs []iso.File
+	err = img.Walk(func(f iso.File) error {
+		ext := strings.ToLower(path.Ext(f.Name))
+		if !f.IsDir && (ext == ".mid" || ext == ".midi") {
+			songs = append(songs, f)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// They all go in the one folder, so songs with the same name in different places on the disc
+	// would land on top of each other: skip exact copies, and rename the rest after their folder
+	songPaths := make(map[string]string) // Lowercase rel => where on the disc it came from
+	for _, song := range songs {
+		rel := path.Join("midi", song.Name)
+		if first, ok := songPaths[strings.ToLower(rel)]; ok {
+			sum, err := hashDiscFile(img, song)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if sum == sums[first] {
+				fmt.Printf("[Install] Same as %s, skipping: %s\n", first, song.Path)
+				continue
+			}
+			dir := path.Base(path.Dir(song.Path))
+			if dir == "." || dir == "/" {
+				dir = "root"
+			}
+			rel = path.Join("midi", dir+"_"+song.Name)
+			if _, ok := songPaths[strings.ToLower(rel)]; ok {
+				log.Fatalf("[Install] Can't install %s: %s is already taken", song.Path, rel)
+			}
+			fmt.Printf("[Install] There's already a %s, so this one's %s: %s\n", song.Name, rel, song.Path)
+		}
+		songPaths[strings.ToLower(rel)] = rel
+		if sums[rel], err = installFile(img, song, filepath.Join(*dest, rel)); err != nil {
+			log.Fatal(err)
+		}
+		check(rel, sums[rel])
+	}
+
+	if err := writeChecksums(filepath.Join(*dest, checksumFile), sums); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[Install] Installed %d files into %s (%d sounds missing)\n", len(sums), *dest, missing)
+	if known != nil {
+		fmt.Printf("[Install] Checked against %s: %d files matched, %d had no known hash\n", *knownFile, len(sums)-unknown, unknown)
+	}
+}
+
+// Copy a file off the disc, and check that what landed on disk hashes the same as what we read.
+// That catches a bad copy, not a bad disc: we don't have the original release's hashes to check against.
+// If it's already there with the right hash, leave it be.
+func installFile(img *iso.Image, f iso.File, dest string) (string, error) {
+	want, err := hashDiscFile(img, f)
+	if err != nil {
+		return "", err
+	}
+	if got, err := hashFile(dest); err == nil && got == want {
+		fmt.Printf("[Install] Already installed: %s\n", dest)
+		return want, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, img.Open(f))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("[Install] copying %s: %w", f.Path, err)
+	}
+
+	got, err := hashFile(dest)
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return "", fmt.Errorf("[Install] checksum mismatch for %s: disc has %s, copy has %s", dest, want, got)
+	}
+	fmt.Printf("[Install] %s => %s\n", f.Path, dest)
+	return want, nil
+}
+
+func hashDiscFile(img *iso.Image, f iso.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, img.Open(f)); err != nil {
+		return "", fmt.Errorf("[Install] reading %s: %w", f.Path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(fileName string) (string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Read hashes in sha256sum's format, keyed by lowercase path
+func loadChecksums(fileName string) (map[string]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ret := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("[Install] %s:%d: expected a SHA-256 hash and a file name", fileName, lineNum)
+		}
+		name := strings.TrimPrefix(strings.ReplaceAll(fields[1], "\\", "/"), "*")
+		ret[strings.ToLower(name)] = strings.ToLower(fields[0])
+	}
+	return ret, scanner.Err()
+}
+
+// Same format as sha256sum, so `sha256sum -c CHECKSUMS` works from the data folder
+func writeChecksums(fileName string, sums map[string]string) error {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s  %s\n", sums[name], name)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -5,35 +5,28 @@ import (
 	"image/color"
 	_ "image/png"
 	"log"
-	"os"
 
+	"github.com/MasterShizzle/goda-stories/bitmapfont"
+	"github.com/MasterShizzle/goda-stories/gosoh"
 	"github.com/blizzy78/ebitenui"
 	"github.com/blizzy78/ebitenui/image"
 	"github.com/blizzy78/ebitenui/widget"
-	"github.com/golang/freetype/truetype"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"golang.org/x/image/font"
 )
 
 // Globals related to the UI
 const ElementBuffer int = 5
 const WindowWidth, WindowHeight int = 1280, 720
 const ViewAspectRatio float64 = 1.2
-const GuiFontFile string = "assets/Cloude_Regular_Bold_1.02.ttf"
+const GuiFontFile string = "assets/font_16x20.png"
 
-func buildGui() *ebitenui.UI {
+func buildGui(uiFont *bitmapfont.Font) *ebitenui.UI {
 	// Build UI elements
 	buttonImg, err := loadButtonImage()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	guiFont, err := loadFont(GuiFontFile, 32)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer guiFont.Close()
-
 	// Root UI container
 	gameContainer := widget.NewContainer(
 		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.RGBA{0, 0, 0, 0})),
@@ -45,16 +38,29 @@ func buildGui() *ebitenui.UI {
 		)),
 	)
 
-	// Make a BUTTON!
-	button := widget.NewButton(
-		widget.ButtonOpts.WidgetOpts(
+	// Everything down the right-hand side, top to bottom
+	sidebar := widget.NewContainer(
+		widget.ContainerOpts.WidgetOpts(
 			widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
 				HorizontalPosition: widget.AnchorLayoutPositionEnd,
 				VerticalPosition:   widget.AnchorLayoutPositionStart,
 			}),
 		),
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(ElementBuffer),
+		)),
+	)
+
+	// Make a BUTTON!
+	button := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionEnd,
+			}),
+		),
 		widget.ButtonOpts.Image(buttonImg),
-		widget.ButtonOpts.Text("Menu", guiFont, &widget.ButtonTextColor{
+		widget.ButtonOpts.Text("Menu", uiFont.Face(1), &widget.ButtonTextColor{
 			Idle: color.RGBA{0xd2, 0xdb, 0xe0, 0xff},
 		}),
 		widget.ButtonOpts.TextPadding(widget.Insets{
@@ -66,7 +72,19 @@ func buildGui() *ebitenui.UI {
 		}),
 	)
 
-	gameContainer.AddChild(button)
+	// The world's seed, so it can be shared (or a bug replayed) with -seed
+	seedText := widget.NewText(
+		widget.TextOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionEnd,
+			}),
+		),
+		widget.TextOpts.Text(fmt.Sprintf("Seed: %d", gosoh.Random.Seed), uiFont.Face(1), color.RGBA{0xd2, 0xdb, 0xe0, 0xff}),
+	)
+
+	sidebar.AddChild(button)
+	sidebar.AddChild(seedText)
+	gameContainer.AddChild(sidebar)
 
 	return &ebitenui.UI{
 		Container: gameContainer,
@@ -104,21 +122,3 @@ func loadNineSlice(path string, w [3]int, h [3]int) (*image.NineSlice, error) {
 
 	return image.NewNineSlice(i, w, h), nil
 }
-
-func loadFont(path string, size float64) (font.Face, error) {
-	fontData, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	ttfFont, err := truetype.Parse(fontData)
-	if err != nil {
-		return nil, err
-	}
-
-	return truetype.NewFace(ttfFont, &truetype.Options{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	}), nil
-}
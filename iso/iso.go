@@ -0,0 +1,242 @@
+// Package iso reads files out of an ISO9660 CD image, like the original game discs.
+// It understands Joliet long names when the disc has them, and plain 8.3 names otherwise.
+package iso
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	ErrNotISO   = errors.New("not an ISO9660 image")
+	ErrNotFound = errors.New("file not found")
+)
+
+// Sectors are 2048 bytes, and the volume descriptors start at sector 16
+const SectorSize = 2048
+const firstDescriptor = 16
+
+// Image is an opened ISO9660 image
+type Image struct {
+	r         io.ReaderAt
+	blockSize int64
+	root      File
+	joliet    bool // Names are UCS-2, from the Joliet supplementary descriptor
+}
+
+// File is a file or directory on the disc
+type File struct {
+	Name   string // Without the ";1" version
+	Path   string // From the root, separated with "/"
+	IsDir  bool
+	Size   int64
+	extent int64 // First block of the file's data
+}
+
+// Open reads the volume descriptors, and picks Joliet over the primary volume if it's there
+func Open(r io.ReaderAt) (*Image, error) {
+	img := &Image{r: r}
+	found := false
+	buf := make([]byte, SectorSize)
+	for sector := int64(firstDescriptor); ; sector++ {
+		if _, err := r.ReadAt(buf, sector*SectorSize); err != nil {
+			return nil, fmt.Errorf("iso: %w: %v", ErrNotISO, err)
+		}
+		if string(buf[1:6]) != "CD001" {
+			return nil, fmt.Errorf("iso: %w: no volume descriptor at sector %d", ErrNotISO, sector)
+		}
+
+		switch buf[0] {
+		case 1: // Primary
+			if !found {
+				img.blockSize = int64(binary.LittleEndian.Uint16(buf[128:]))
+				img.root = parseRecord(buf[156:190], false)
+				found = true
+			}
+		case 2: // Supplementary: Joliet if it has one of the UCS-2 escape sequences
+			esc := string(buf[88:91])
+			if esc == "%/@" || esc == "%/C" || esc == "%/E" {
+				img.blockSize = int64(binary.LittleEndian.Uint16(buf[128:]))
+				img.root = parseRecord(buf[156:190], true)
+				img.joliet = true
+				found = true
+			}
+		case 255: // Terminator
+			if !found {
+				return nil, fmt.Errorf("iso: %w: no primary volume descriptor", ErrNotISO)
+			}
+			if img.blockSize == 0 {
+				img.blockSize = SectorSize
+			}
+			img.root.Name, img.root.Path, img.root.IsDir = "", "", true
+			return img, nil
+		}
+	}
+}
+
+// Parse a directory record: 33 bytes, then the name
+func parseRecord(rec []byte, joliet bool) File {
+	f := File{
+		extent: int64(binary.LittleEndian.Uint32(rec[2:])),
+		Size:   int64(binary.LittleEndian.Uint32(rec[10:])),
+		IsDir:  rec[25]&0x02 != 0,
+	}
+	nameLen := int(rec[32])
+	if 33+nameLen > len(rec) {
+		nameLen = len(rec) - 33
+	}
+	name := rec[33 : 33+nameLen]
+
+	if joliet && nameLen > 1 {
+		u := make([]uint16, nameLen/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(name[i*2:])
+		}
+		f.Name = string(utf16.Decode(u))
+	} else {
+		f.Name = string(name)
+	}
+
+	// "BLASTER.WAV;1" => "BLASTER.WAV", and "README.;1" => "README"
+	if i := strings.LastIndexByte(f.Name, ';'); i >= 0 {
+		f.Name = f.Name[:i]
+	}
+	f.Name = strings.TrimSuffix(f.Name, ".")
+	return f
+}
+
+// Read the entries of a directory, not counting "." and ".."
+func (img *Image) readDir(dir File) ([]File, error) {
+	data := make([]byte, dir.Size)
+	if _, err := img.r.ReadAt(data, dir.extent*img.blockSize); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("iso: reading %s: %w", dir.Path, err)
+	}
+
+	ret := make([]File, 0)
+	for pos := 0; pos < len(data); {
+		recLen := int(data[pos])
+		if recLen == 0 {
+			// Records don't cross sectors: skip the padding to the next one
+			pos = (pos/SectorSize + 1) * SectorSize
+			continue
+		}
+		if recLen < 34 || pos+recLen > len(data) {
+			return nil, fmt.Errorf("iso: bad directory record in %s at 0x%x", dir.Path, pos)
+		}
+		rec := data[pos : pos+recLen]
+		pos += recLen
+
+		// Name "\x00" is this directory, "\x01" is the parent
+		if rec[32] == 1 && (rec[33] == 0 || rec[33] == 1) {
+			continue
+		}
+		f := parseRecord(rec, img.joliet)
+		f.Path = path.Join(dir.Path, f.Name)
+		ret = append(ret, f)
+	}
+	return ret, nil
+}
+
+// Walk calls fn for every file and directory on the disc
+func (img *Image) Walk(fn func(f File) error) error {
+	seen := make(map[int64]bool)
+	var walk func(dir File) error
+	walk = func(dir File) error {
+		// Don't go in circles, if the disc is weird
+		if seen[dir.extent] {
+			return nil
+		}
+		seen[dir.extent] = true
+
+		entries, err := img.readDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range entries {
+			if err := fn(f); err != nil {
+				return err
+			}
+			if f.IsDir {
+				if err := walk(f); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(img.root)
+}
+
+// Find the first file whose name matches (see MatchName), anywhere on the disc
+func (img *Image) Find(name string) (File, error) {
+	var ret File
+	errFound := errors.New("found")
+	err := img.Walk(func(f File) error {
+		if !f.IsDir && MatchName(name, f.Name) {
+			ret = f
+			return errFound
+		}
+		return nil
+	})
+	if err == errFound {
+		return ret, nil
+	} else if err != nil {
+		return File{}, err
+	}
+	return File{}, fmt.Errorf("iso: %w: %s", ErrNotFound, name)
+}
+
+// Reader for a file's contents
+func (img *Image) Open(f File) io.Reader {
+	return io.NewSectionReader(img.r, f.extent*img.blockSize, f.Size)
+}
+
+// MatchName compares file names the way DOS would: ignoring case, and letting
+// a long name match its 8.3 short name (e.g. "LukeSaber.wav" and "LUKESA~1.WAV")
+func MatchName(want, have string) bool {
+	return strings.EqualFold(want, have) ||
+		strings.EqualFold(ShortName(want), have) ||
+		strings.EqualFold(want, ShortName(have))
+}
+
+// ShortName makes the usual 8.3 alias for a long file name.
+// Names that already fit come back as-is (but uppercase).
+func ShortName(name string) string {
+	base, ext := name, ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+	clean := func(s string) string {
+		s = strings.ToUpper(s)
+		return strings.Map(func(r rune) rune {
+			if r == ' ' || r == '.' || r > 127 {
+				return -1
+			}
+			if strings.ContainsRune("+,;=[]", r) {
+				return '_'
+			}
+			return r
+		}, s)
+	}
+	cleanBase, cleanExt := clean(base), clean(ext)
+	fits := len(cleanBase) <= 8 && len(cleanExt) <= 3 && cleanBase == strings.ToUpper(base) && cleanExt == strings.ToUpper(ext)
+
+	if !fits {
+		if len(cleanBase) > 6 {
+			cleanBase = cleanBase[:6]
+		}
+		cleanBase += "~1"
+		if len(cleanExt) > 3 {
+			cleanExt = cleanExt[:3]
+		}
+	}
+	if cleanExt == "" {
+		return cleanBase
+	}
+	return cleanBase + "." + cleanExt
+}
@@ -1,39 +1,87 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/MasterShizzle/goda-stories/dta"
 	"github.com/MasterShizzle/goda-stories/gosoh"
+	"github.com/MasterShizzle/goda-stories/worldgen"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-const yodaFile = "YODESK.DTA"
+var exportTiled = flag.Bool("tiled", false, "save the tileset image and every zone as Tiled files, under assets/")
+var mapsDir = flag.String("maps", "", "load zones from the Tiled maps in this folder, replacing the originals with the same Id")
+var scriptsDir = flag.String("scripts", "", "load zone scripts from the zone_NNN.iact files in this folder, replacing the originals")
+var mapImage = flag.String("mapimage", "", "save the starting map area to this PNG, with zone borders, hotspots, actors and blocked tiles marked")
+var seed = flag.Int64("seed", 0, "seed for the world and everything else random, to get the same game again; 0 picks one")
+var worldSize = flag.String("size", "medium", "how big the planet is: small, medium or large")
+var biome = flag.String("biome", "random", "which planet to go to: desert, snow, forest or random")
+var puzzleSteps = flag.Int("steps", 0, "how many puzzles to solve before the last one; 0 for the usual number for the size")
+var mainQuest = flag.Int("quest", -1, "Id of the MainQuest puzzle to finish on; -1 picks one")
+var sfxDir = flag.String("sfx", gosoh.SfxDir, "folder with the game's WAV sound effects")
+var eventSounds = flag.String("eventsounds", "", "SNDS index to play for engine sounds, e.g. bump=12,pickup=3,hit=7; ones left out get guessed from the file names")
+var soundFont = flag.String("soundfont", "", "SoundFont (.sf2) to play the music with, instead of the built-in synth")
+var useCache = flag.Bool("cache", true, "keep the parsed data file in the user cache dir, to start faster next time")
 
 func main() {
-	// TODO:
-	//  - Figure out how to grab YODESK.dta and sounds and junk
-	//      via extracting from the ISO directly
-	//  	- Make an Installer
-	//  - action scripts
-	//  - worldgen rules
-	var tileset *ebiten.Image
-	var tileInfo []gosoh.TileInfo
-	var zoneInfo []gosoh.ZoneInfo
-	var itemInfo []gosoh.ItemInfo
-	var puzzleInfo []gosoh.PuzzleInfo
-	var creatureInfo []gosoh.CreatureInfo
-	var soundList []string
-
-	tileset, tileInfo, zoneInfo, itemInfo, puzzleInfo, creatureInfo, soundList = processYodaFile(yodaFile)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "install":
+			runInstall(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		}
+	}
+	flag.Parse()
+	gosoh.SfxDir = *sfxDir
+	gosoh.SoundFontPath = *soundFont
+	if err := parseEventSounds(*eventSounds); err != nil {
+		log.Fatal(err)
+	}
+
+	data, tileset := loadGameData(findDataFile(), *useCache)
+	if *mapsDir != "" {
+		var err error
+		if data.Zones, err = loadTiledZones(*mapsDir, data.Zones); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *scriptsDir != "" {
+		if err := loadZoneScripts(*scriptsDir, data.Zones, len(data.Tiles)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts, err := gameOptionsFromFlags(data)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Init the game
-	g := NewGame(tileset, tileInfo, zoneInfo, itemInfo, puzzleInfo, creatureInfo, soundList)
+	g, err := NewGame(data, tileset, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Create various output files
-	// TODO: This should probably be in the installer, or optional
-	if true {
+	// Create various output files, if asked to
+	if *exportTiled {
+		if err := saveTilesetImage(tileset); err != nil {
+			log.Fatal(err)
+		}
 		saveTiledMaps(g)
 	}
+	if *mapImage != "" {
+		if err := saveMapImage(g, *mapImage); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	ebiten.SetWindowResizable(true)
 	ebiten.SetWindowTitle("Goda Stories")
@@ -44,3 +92,80 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// Check the new game flags, and turn them into NewGameOptions
+func gameOptionsFromFlags(data *dta.File) (NewGameOptions, error) {
+	opts := DefaultGameOptions()
+	opts.Seed = *seed
+	opts.PuzzleSteps = *puzzleSteps
+	opts.MainQuest = *mainQuest
+
+	// If the planet was asked for, it has to be that planet or nothing
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "size", "biome", "steps", "quest":
+			opts.Strict = true
+		}
+	})
+
+	size, ok := worldgen.Sizes[*worldSize]
+	if !ok {
+		return opts, fmt.Errorf("no world size %q: it's small, medium or large", *worldSize)
+	}
+	opts.Width, opts.Height = size, size
+
+	if *biome != "random" {
+		ok = false
+		for _, b := range worldgen.Biomes {
+			ok = ok || b == *biome
+		}
+		if !ok {
+			return opts, fmt.Errorf("no %q planet: it's desert, snow, forest or random", *biome)
+		}
+		opts.Biome = *biome
+	}
+	if *puzzleSteps < 0 {
+		return opts, fmt.Errorf("can't have %d puzzle steps", *puzzleSteps)
+	}
+	if *mainQuest >= 0 {
+		quests := worldgen.MainQuests(data.Puzzles)
+		ok = false
+		for _, id := range quests {
+			ok = ok || id == *mainQuest
+		}
+		if !ok {
+			return opts, fmt.Errorf("puzzle %d isn't a MainQuest: they're %v", *mainQuest, quests)
+		}
+	}
+	return opts, nil
+}
+
+// Fill in gosoh.EventSounds from something like "bump=12,pickup=3"
+func parseEventSounds(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("bad event sound %q: it's event=index", field)
+		}
+		sndId, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Errorf("bad sound index %q for %s", parts[1], parts[0])
+		}
+		found := false
+		names := make([]string, 0)
+		for evt := gosoh.SoundEvent(0); evt < gosoh.NumSoundEvents; evt++ {
+			names = append(names, evt.ToString())
+			if evt.ToString() == parts[0] {
+				gosoh.EventSounds[evt] = sndId
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no sound event %q: it's one of %s", parts[0], strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
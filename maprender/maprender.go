@@ -0,0 +1,213 @@
+// Package maprender draws a whole map area (any grid of zones, from Dagobah's 2x2 to a full planet)
+// to one big image, with optional overlays for debugging worldgen and scripts.
+// It only uses image/draw, so it works headless: no ebiten, no window.
+package maprender
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Tiles are 32x32 px
+const TileSize = 32
+
+// No tile, in any of the layers
+const noTile = 65535
+
+var (
+	borderColor   = color.NRGBA{R: 255, G: 255, B: 0, A: 255}
+	hotspotColor  = color.NRGBA{R: 0, G: 255, B: 255, A: 255}
+	disabledColor = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	actorColor    = color.NRGBA{R: 255, G: 128, B: 0, A: 255}
+	blockedColor  = color.NRGBA{R: 255, G: 0, B: 0, A: 96}
+	labelBack     = color.NRGBA{A: 192}
+)
+
+// Which overlays to draw on top of the tiles
+type Options struct {
+	ZoneBorders bool // Outline each zone, with its ID in the corner
+	Hotspots    bool // Box each hotspot, labelled with its type
+	Actors      bool // Draw each zone's creatures where they start
+	Blocked     bool // Tint the tiles you can't walk on
+}
+
+// One spot on the map, with a tile ID (or 65535) for each layer
+type Tile struct {
+	Terrain  int
+	Walls    int
+	Overlay  int
+	Walkable bool
+}
+
+// A grid of zones, laid out like gosoh.MapArea: Zones and Tiles are both [x][y]
+type Area struct {
+	Width  int // In zones
+	Height int
+	Zones  [][]*dta.ZoneInfo
+	Tiles  [][]Tile
+
+	// By zone ID, whether each hotspot is on (1) or off (0) right now, if scripts have had a go at them.
+	// Zones that aren't in here get drawn the way their data says.
+	HotspotEnabled map[int][]int
+}
+
+// An empty area, w x h zones of 18x18 tiles
+func NewArea(w, h int) *Area {
+	a := &Area{Width: w, Height: h, HotspotEnabled: make(map[int][]int)}
+	a.Zones = make([][]*dta.ZoneInfo, w)
+	for x := range a.Zones {
+		a.Zones[x] = make([]*dta.ZoneInfo, h)
+	}
+	a.Tiles = make([][]Tile, w*18)
+	for x := range a.Tiles {
+		a.Tiles[x] = make([]Tile, h*18)
+		for y := range a.Tiles[x] {
+			a.Tiles[x][y] = Tile{Terrain: noTile, Walls: noTile, Overlay: noTile, Walkable: true}
+		}
+	}
+	return a
+}
+
+// Put a zone's tiles at zone coords x,y; walkability comes from the Walls layer, like in the game
+func (a *Area) AddZone(z *dta.ZoneInfo, x, y int, tiles []dta.TileInfo) {
+	a.Zones[x][y] = z
+	for j := 0; j < z.Height; j++ {
+		for i := 0; i < z.Width; i++ {
+			tx, ty := x*18+i, y*18+j
+			if tx >= len(a.Tiles) || ty >= len(a.Tiles[tx]) {
+				continue
+			}
+			idx := j*z.Width + i
+			t := Tile{
+				Terrain:  z.TileMaps.Terrain[idx],
+				Walls:    z.TileMaps.Walls[idx],
+				Overlay:  z.TileMaps.Overlay[idx],
+				Walkable: true,
+			}
+			if t.Walls >= 0 && t.Walls < len(tiles) {
+				t.Walkable = tiles[t.Walls].IsWalkable
+			}
+			a.Tiles[tx][ty] = t
+		}
+	}
+}
+
+// Draw the area's three layers on top of each other, then whichever overlays are asked for.
+// Tile and creature IDs index into tiles and creatures, same as in the data file.
+func Render(a *Area, tiles []dta.TileInfo, creatures []dta.CreatureInfo, opts Options) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, len(a.Tiles)*TileSize, a.Height*18*TileSize))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	tileImages := make(map[int]*image.NRGBA)
+	drawTile := func(tNum, px, py int) {
+		if tNum < 0 || tNum >= len(tiles) {
+			return
+		}
+		t, ok := tileImages[tNum]
+		if !ok {
+			t = dta.PaletteImage(tiles[tNum].Pixels, TileSize, TileSize)
+			tileImages[tNum] = t
+		}
+		draw.Draw(img, image.Rect(px, py, px+TileSize, py+TileSize), t, image.Point{}, draw.Over)
+	}
+
+	for x := range a.Tiles {
+		for y := range a.Tiles[x] {
+			// Gaps in the grid stay black
+			if a.Zones[x/18][y/18] == nil {
+				continue
+			}
+			t := a.Tiles[x][y]
+			px, py := x*TileSize, y*TileSize
+			drawTile(t.Terrain, px, py)
+			drawTile(t.Walls, px, py)
+			drawTile(t.Overlay, px, py)
+			if opts.Blocked && !t.Walkable {
+				draw.Draw(img, image.Rect(px, py, px+TileSize, py+TileSize), image.NewUniform(blockedColor), image.Point{}, draw.Over)
+			}
+		}
+	}
+
+	for zx := range a.Zones {
+		for zy, z := range a.Zones[zx] {
+			if z == nil {
+				continue
+			}
+			// Top left of the zone, in pixels
+			ox, oy := zx*18*TileSize, zy*18*TileSize
+
+			if opts.Actors {
+				for _, act := range z.ZoneActors {
+					px, py := ox+act.ZoneX*TileSize, oy+act.ZoneY*TileSize
+					drawTile(creatureTile(creatures, act.CreatureId), px, py)
+					outline(img, image.Rect(px, py, px+TileSize, py+TileSize), actorColor)
+				}
+			}
+			if opts.Hotspots {
+				live := a.HotspotEnabled[z.Id]
+				for i, hs := range z.Hotspots {
+					px, py := ox+hs.X*TileSize, oy+hs.Y*TileSize
+					enabled := hs.Enabled
+					if i < len(live) {
+						enabled = live[i]
+					}
+					c := hotspotColor
+					if enabled == 0 {
+						c = disabledColor
+					}
+					outline(img, image.Rect(px+1, py+1, px+TileSize-1, py+TileSize-1), c)
+					label(img, px+2, py+TileSize-2, hs.Type.ToString(), c)
+				}
+			}
+			if opts.ZoneBorders {
+				outline(img, image.Rect(ox, oy, ox+z.Width*TileSize, oy+z.Height*TileSize), borderColor)
+				label(img, ox+3, oy+14, fmt.Sprintf("%03d", z.Id), borderColor)
+			}
+		}
+	}
+	return img
+}
+
+// The tile a creature shows when it's standing still, facing down-ish; -1 if there isn't one
+func creatureTile(creatures []dta.CreatureInfo, cNum int) int {
+	if cNum < 0 || cNum >= len(creatures) {
+		return -1
+	}
+	for _, dir := range []dta.CardinalDirection{dta.Down, dta.DownLeft, dta.DownRight} {
+		if tNum, ok := creatures[cNum].Images[dir]; ok && tNum != noTile {
+			return tNum
+		}
+	}
+	return -1
+}
+
+// A 1px box just inside r
+func outline(img *image.NRGBA, r image.Rectangle, c color.Color) {
+	u := image.NewUniform(c)
+	draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), u, image.Point{}, draw.Src)
+}
+
+// Text on a dark box, with x,y being the left end of the baseline
+func label(img *image.NRGBA, x, y int, text string, c color.Color) {
+	face := basicfont.Face7x13
+	w := font.MeasureString(face, text).Ceil()
+	back := image.Rect(x-1, y-face.Ascent-1, x+w+1, y+face.Descent)
+	draw.Draw(img, back, image.NewUniform(labelBack), image.Point{}, draw.Over)
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
@@ -0,0 +1,93 @@
+package midi
+
+import (
+	"io"
+	"math"
+)
+
+// Player renders a MIDI file through a Synth, as signed 16-bit little-endian stereo PCM.
+// That's what ebiten's audio players want, so it can be handed straight to one.
+type Player struct {
+	Synth *Synth
+	Loop  bool // Start over at the end, instead of stopping
+
+	events []TimedEvent
+	next   int   // Index of the next event to send
+	frame  int64 // How many frames we've rendered since the start (or the last loop)
+	end    int64 // Frame the song ends on
+	buf    []float32
+}
+
+func NewPlayer(f *File, s *Synth, loop bool) *Player {
+	p := &Player{
+		Synth:  s,
+		Loop:   loop,
+		events: f.Timeline(),
+	}
+	if len(p.events) > 0 {
+		p.end = p.eventFrame(len(p.events) - 1)
+	}
+	return p
+}
+
+func (p *Player) eventFrame(i int) int64 {
+	return int64(math.Round(p.events[i].Seconds * float64(p.Synth.SampleRate)))
+}
+
+// Back to the beginning, with no notes playing
+func (p *Player) Rewind() {
+	p.next = 0
+	p.frame = 0
+	p.Synth.Reset()
+}
+
+// Read fills b with PCM; it only returns io.EOF once a non-looping song has finished ringing out
+func (p *Player) Read(b []byte) (int, error) {
+	frames := len(b) / 4
+	if frames == 0 {
+		return 0, nil
+	}
+	if len(p.buf) < frames*2 {
+		p.buf = make([]float32, frames*2)
+	}
+
+	done := 0
+	for done < frames {
+		// Send everything that's due
+		for p.next < len(p.events) && p.eventFrame(p.next) <= p.frame {
+			p.Synth.Send(p.events[p.next].Event)
+			p.next++
+		}
+
+		finished := p.next >= len(p.events) && p.frame >= p.end
+		if finished && p.Loop && p.end > 0 {
+			p.Rewind()
+			continue
+		}
+		if finished && p.Synth.Active() == 0 {
+			break
+		}
+
+		// Render up until the next event, or as much as we've got room for
+		n := int64(frames - done)
+		if p.next < len(p.events) {
+			if until := p.eventFrame(p.next) - p.frame; until < n {
+				n = until
+			}
+		}
+		out := p.buf[done*2 : (done+int(n))*2]
+		p.Synth.Render(out)
+		done += int(n)
+		p.frame += n
+	}
+
+	for i := 0; i < done*2; i++ {
+		v := int16(math.Max(-1, math.Min(1, float64(p.buf[i]))) * 32767)
+		b[i*2] = byte(v)
+		b[i*2+1] = byte(v >> 8)
+	}
+	if done == 0 {
+		return 0, io.EOF
+	}
+	return done * 4, nil
+}
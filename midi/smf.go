@@ -0,0 +1,246 @@
+// Package midi reads Standard MIDI Files and plays them through a small software synth.
+// Like dta, it doesn't need ebiten: a Player is just an io.Reader of 16-bit stereo PCM.
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var (
+	ErrNotMIDI      = errors.New("not a MIDI file")
+	ErrBadFormat    = errors.New("bad MIDI data")
+	ErrBadSoundFont = errors.New("bad SoundFont data")
+)
+
+// File holds every track of a Standard MIDI File
+type File struct {
+	Format   int // 0: one track, 1: tracks played together, 2: tracks played one after another
+	Division int // Ticks per quarter note; negative means SMPTE timing (see TickSeconds)
+	Tracks   []Track
+}
+
+type Track []Event
+
+type Event struct {
+	Tick   int  // Absolute, from the start of the track
+	Status byte // 0x80-0xEF for channel messages, 0xFF for meta events, 0xF0 / 0xF7 for sysex
+	Data1  byte
+	Data2  byte
+	Meta   byte   // Meta event type, when Status is 0xFF
+	Data   []byte // Meta / sysex payload
+}
+
+// Meta events we care about
+const (
+	MetaEndOfTrack byte = 0x2F
+	MetaTempo      byte = 0x51
+)
+
+func (e Event) Channel() int {
+	return int(e.Status & 0x0F)
+}
+
+// The kind of channel message, without the channel: 0x80 note off, 0x90 note on, etc.
+func (e Event) Kind() byte {
+	return e.Status & 0xF0
+}
+
+// Parse reads a whole Standard MIDI File
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("midi: %w", err)
+	}
+	if len(data) < 14 || string(data[:4]) != "MThd" {
+		return nil, fmt.Errorf("midi: %w", ErrNotMIDI)
+	}
+
+	f := &File{}
+	headerLen := int(binary.BigEndian.Uint32(data[4:8]))
+	if headerLen < 6 || 8+headerLen > len(data) {
+		return nil, fmt.Errorf("midi: %w: header length %d", ErrBadFormat, headerLen)
+	}
+	f.Format = int(binary.BigEndian.Uint16(data[8:10]))
+	numTracks := int(binary.BigEndian.Uint16(data[10:12]))
+	f.Division = int(int16(binary.BigEndian.Uint16(data[12:14])))
+	if f.Division == 0 {
+		return nil, fmt.Errorf("midi: %w: division is 0", ErrBadFormat)
+	}
+
+	// Then the tracks, skipping any chunks we don't know about
+	pos := 8 + headerLen
+	for pos+8 <= len(data) && len(f.Tracks) < numTracks {
+		chunkType := string(data[pos : pos+4])
+		chunkLen := int(binary.BigEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8
+		if chunkLen < 0 || pos+chunkLen > len(data) {
+			return nil, fmt.Errorf("midi: %w: %s chunk at 0x%x runs past the end", ErrBadFormat, chunkType, pos-8)
+		}
+		if chunkType == "MTrk" {
+			trk, err := parseTrack(data[pos : pos+chunkLen])
+			if err != nil {
+				return nil, fmt.Errorf("midi: track %d: %w", len(f.Tracks), err)
+			}
+			f.Tracks = append(f.Tracks, trk)
+		}
+		pos += chunkLen
+	}
+
+	return f, nil
+}
+
+func parseTrack(data []byte) (Track, error) {
+	r := bytes.NewReader(data)
+	trk := make(Track, 0)
+	tick := 0
+	var running byte
+
+	for r.Len() > 0 {
+		delta, err := readVarLen(r)
+		if err != nil {
+			return nil, err
+		}
+		tick += delta
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadFormat, err)
+		}
+		e := Event{Tick: tick}
+
+		switch {
+		case b == 0xFF:
+			e.Status = b
+			if e.Meta, err = r.ReadByte(); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrBadFormat, err)
+			}
+			if e.Data, err = readBlock(r); err != nil {
+				return nil, err
+			}
+		case b == 0xF0 || b == 0xF7:
+			// Sysex cancels running status
+			running = 0
+			e.Status = b
+			if e.Data, err = readBlock(r); err != nil {
+				return nil, err
+			}
+		case b >= 0x80:
+			running = b
+			e.Status = b
+			if e.Data1, err = r.ReadByte(); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrBadFormat, err)
+			}
+		default:
+			// Running status: this byte was the first data byte
+			if running == 0 {
+				return nil, fmt.Errorf("%w: data byte 0x%02x without a status", ErrBadFormat, b)
+			}
+			e.Status = running
+			e.Data1 = b
+		}
+
+		// Program change and channel pressure only have the one data byte
+		if e.Status < 0xF0 && e.Kind() != 0xC0 && e.Kind() != 0xD0 {
+			if e.Data2, err = r.ReadByte(); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrBadFormat, err)
+			}
+		}
+
+		trk = append(trk, e)
+		if e.Status == 0xFF && e.Meta == MetaEndOfTrack {
+			break
+		}
+	}
+
+	return trk, nil
+}
+
+// MIDI's variable-length numbers: 7 bits per byte, high bit set on all but the last
+func readVarLen(r io.ByteReader) (int, error) {
+	ret := 0
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrBadFormat, err)
+		}
+		ret = (ret << 7) | int(b&0x7F)
+		if b&0x80 == 0 {
+			return ret, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: variable-length number is too long", ErrBadFormat)
+}
+
+func readBlock(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarLen(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > r.Len() {
+		return nil, fmt.Errorf("%w: %d byte block runs past the end", ErrBadFormat, n)
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return b, nil
+}
+
+// A TimedEvent is an Event, with when it happens in seconds
+type TimedEvent struct {
+	Event
+	Seconds float64
+}
+
+// Timeline merges the tracks into one list of events, in order, with the tempo changes worked out
+func (f *File) Timeline() []TimedEvent {
+	ret := make([]TimedEvent, 0)
+	offset := 0
+	for _, trk := range f.Tracks {
+		for _, e := range trk {
+			e.Tick += offset
+			ret = append(ret, TimedEvent{Event: e})
+		}
+		// Format 2 tracks are separate songs, so play them one after another
+		if f.Format == 2 && len(trk) > 0 {
+			offset = trk[len(trk)-1].Tick + offset
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Tick < ret[j].Tick
+	})
+
+	// Default tempo is 120 BPM, i.e. 500000 microseconds per quarter note
+	tempo := 500000
+	lastTick := 0
+	seconds := 0.0
+	for i := range ret {
+		seconds += float64(ret[i].Tick-lastTick) * f.TickSeconds(tempo)
+		lastTick = ret[i].Tick
+		ret[i].Seconds = seconds
+
+		if ret[i].Status == 0xFF && ret[i].Meta == MetaTempo && len(ret[i].Data) == 3 {
+			d := ret[i].Data
+			tempo = int(d[0])<<16 | int(d[1])<<8 | int(d[2])
+		}
+	}
+
+	return ret
+}
+
+// How long one tick lasts, at the given tempo (microseconds per quarter note)
+func (f *File) TickSeconds(tempo int) float64 {
+	if f.Division < 0 {
+		// SMPTE: the high byte is -frames per second, and the low byte is ticks per frame
+		fps := -int(int8(f.Division >> 8))
+		ticksPerFrame := f.Division & 0xFF
+		if fps == 0 || ticksPerFrame == 0 {
+			return 0
+		}
+		return 1 / float64(fps*ticksPerFrame)
+	}
+	return float64(tempo) / 1000000 / float64(f.Division)
+}
@@ -0,0 +1,453 @@
+package midi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// SoundFont is a parsed .sf2 file: sampled instruments, to use instead of the built-in oscillators.
+// Only the basics are supported: key / velocity ranges, tuning, loops, pan, attenuation
+// and the attack / release of the volume envelope. Modulators are ignored.
+type SoundFont struct {
+	Name    string
+	Presets []Preset
+
+	samples []int16 // Every sample in the file, as one big block
+}
+
+// A Preset is what a MIDI program change picks
+type Preset struct {
+	Name    string
+	Bank    int
+	Program int
+	Zones   []Zone // One per sample, with the key and velocity ranges that play it
+}
+
+// A Zone ties a sample to the notes that play it, and how it should sound
+type Zone struct {
+	KeyLo, KeyHi int
+	VelLo, VelHi int
+	Sample       Sample
+	RootKey      int     // The key that plays the sample at its recorded pitch
+	Tune         float64 // In semitones
+	Loop         bool
+	Pan          float64 // -1 (left) to 1 (right)
+	Attenuation  float64 // In decibels
+	Attack       float64 // In seconds
+	Hold         float64
+	Decay        float64
+	Sustain      float64 // Level, from 0 to 1
+	Release      float64
+}
+
+type Sample struct {
+	Name               string
+	Start, End         int // Indexes into the SoundFont's samples
+	LoopStart, LoopEnd int
+	SampleRate         int
+	OriginalKey        int
+	Correction         int // In cents
+}
+
+// SoundFont generator numbers that we use
+const (
+	genStartOffset       = 0
+	genEndOffset         = 1
+	genLoopStartOffset   = 2
+	genLoopEndOffset     = 3
+	genStartCoarseOffset = 4
+	genEndCoarseOffset   = 12
+	genPan               = 17
+	genAttackVolEnv      = 34
+	genHoldVolEnv        = 35
+	genDecayVolEnv       = 36
+	genSustainVolEnv     = 37
+	genReleaseVolEnv     = 38
+	genInstrument        = 41
+	genKeyRange          = 43
+	genVelRange          = 44
+	genLoopStartCoarse   = 45
+	genAttenuation       = 48
+	genLoopEndCoarse     = 50
+	genCoarseTune        = 51
+	genFineTune          = 52
+	genSampleId          = 53
+	genSampleModes       = 54
+	genRootKey           = 58
+)
+
+// Raw records from the pdta chunk
+type sfHeader struct {
+	name         string
+	a, b, bagNdx int // For presets: program, bank, bag index; for instruments, just the bag index
+}
+
+type sfGen struct {
+	op     int
+	amount uint16
+}
+
+// ParseSoundFont reads a whole .sf2 file
+func ParseSoundFont(r io.Reader) (*SoundFont, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("sf2: %w", err)
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "sfbk" {
+		return nil, fmt.Errorf("sf2: %w: not a SoundFont", ErrBadSoundFont)
+	}
+
+	chunks := make(map[string][]byte)
+	if err := riffChunks(data[12:], chunks); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"smpl", "phdr", "pbag", "pgen", "inst", "ibag", "igen", "shdr"} {
+		if _, ok := chunks[name]; !ok {
+			return nil, fmt.Errorf("sf2: %w: missing %s chunk", ErrBadSoundFont, name)
+		}
+	}
+
+	sf := &SoundFont{Name: cString(chunks["INAM"])}
+	smpl := chunks["smpl"]
+	sf.samples = make([]int16, len(smpl)/2)
+	for i := range sf.samples {
+		sf.samples[i] = int16(binary.LittleEndian.Uint16(smpl[i*2:]))
+	}
+
+	phdr := sfHeaders(chunks["phdr"], 38, true)
+	inst := sfHeaders(chunks["inst"], 22, false)
+	pbag := sfBags(chunks["pbag"])
+	ibag := sfBags(chunks["ibag"])
+	pgen := sfGens(chunks["pgen"])
+	igen := sfGens(chunks["igen"])
+	samples := sfSamples(chunks["shdr"])
+
+	// The last header of each list is just a terminator
+	for i := 0; i+1 < len(phdr); i++ {
+		p := Preset{Name: phdr[i].name, Program: phdr[i].a, Bank: phdr[i].b}
+		var global []sfGen
+		for b := phdr[i].bagNdx; b < phdr[i+1].bagNdx && b+1 < len(pbag); b++ {
+			gens := sliceGens(pgen, pbag[b], pbag[b+1])
+			instId, ok := findGen(gens, genInstrument)
+			if !ok {
+				// No instrument means a global zone, for the rest of the preset
+				if b == phdr[i].bagNdx {
+					global = gens
+				}
+				continue
+			}
+			if int(instId)+1 >= len(inst) {
+				return nil, fmt.Errorf("sf2: %w: preset %q uses instrument %d", ErrBadSoundFont, p.Name, instId)
+			}
+			presetGens := append(append([]sfGen{}, global...), gens...)
+			zones, err := sf.instrumentZones(inst, ibag, igen, samples, int(instId), presetGens)
+			if err != nil {
+				return nil, err
+			}
+			p.Zones = append(p.Zones, zones...)
+		}
+		sf.Presets = append(sf.Presets, p)
+	}
+
+	return sf, nil
+}
+
+// Flatten the RIFF chunk tree into a map by chunk ID; LISTs get opened up
+func riffChunks(data []byte, chunks map[string][]byte) error {
+	for len(data) >= 8 {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			return fmt.Errorf("sf2: %w: %s chunk runs past the end", ErrBadSoundFont, id)
+		}
+		if id == "LIST" && size >= 4 {
+			if err := riffChunks(data[4:size], chunks); err != nil {
+				return err
+			}
+		} else {
+			chunks[id] = data[:size]
+		}
+		// Chunks are padded to an even length
+		size += size & 1
+		if size > len(data) {
+			size = len(data)
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+func sfHeaders(data []byte, size int, preset bool) []sfHeader {
+	ret := make([]sfHeader, 0, len(data)/size)
+	for i := 0; i+size <= len(data); i += size {
+		h := sfHeader{name: cString(data[i : i+20])}
+		if preset {
+			h.a = int(binary.LittleEndian.Uint16(data[i+20:]))
+			h.b = int(binary.LittleEndian.Uint16(data[i+22:]))
+			h.bagNdx = int(binary.LittleEndian.Uint16(data[i+24:]))
+		} else {
+			h.bagNdx = int(binary.LittleEndian.Uint16(data[i+20:]))
+		}
+		ret = append(ret, h)
+	}
+	return ret
+}
+
+// Each bag is 4 bytes: where its generators start, then where its modulators start
+func sfBags(data []byte) []int {
+	ret := make([]int, 0, len(data)/4)
+	for i := 0; i+4 <= len(data); i += 4 {
+		ret = append(ret, int(binary.LittleEndian.Uint16(data[i:])))
+	}
+	return ret
+}
+
+func sfGens(data []byte) []sfGen {
+	ret := make([]sfGen, 0, len(data)/4)
+	for i := 0; i+4 <= len(data); i += 4 {
+		ret = append(ret, sfGen{
+			op:     int(binary.LittleEndian.Uint16(data[i:])),
+			amount: binary.LittleEndian.Uint16(data[i+2:]),
+		})
+	}
+	return ret
+}
+
+func sfSamples(data []byte) []Sample {
+	ret := make([]Sample, 0, len(data)/46)
+	for i := 0; i+46 <= len(data); i += 46 {
+		d := data[i : i+46]
+		ret = append(ret, Sample{
+			Name:        cString(d[:20]),
+			Start:       int(binary.LittleEndian.Uint32(d[20:])),
+			End:         int(binary.LittleEndian.Uint32(d[24:])),
+			LoopStart:   int(binary.LittleEndian.Uint32(d[28:])),
+			LoopEnd:     int(binary.LittleEndian.Uint32(d[32:])),
+			SampleRate:  int(binary.LittleEndian.Uint32(d[36:])),
+			OriginalKey: int(d[40]),
+			Correction:  int(int8(d[41])),
+		})
+	}
+	return ret
+}
+
+func sliceGens(gens []sfGen, from, to int) []sfGen {
+	if from < 0 || to > len(gens) || from > to {
+		return nil
+	}
+	return gens[from:to]
+}
+
+// The last one wins, so a zone's own generators override its global zone
+func findGen(gens []sfGen, op int) (uint16, bool) {
+	var ret uint16
+	found := false
+	for _, g := range gens {
+		if g.op == op {
+			ret, found = g.amount, true
+		}
+	}
+	return ret, found
+}
+
+func genInt(gens []sfGen, op, def int) int {
+	if v, ok := findGen(gens, op); ok {
+		return int(int16(v))
+	}
+	return def
+}
+
+// Envelope times are in "timecents": 1200 * log2(seconds)
+func genSeconds(gens []sfGen, op int) float64 {
+	return math.Pow(2, float64(genInt(gens, op, -12000))/1200)
+}
+
+func (sf *SoundFont) instrumentZones(inst []sfHeader, ibag []int, igen []sfGen, samples []Sample, instId int, presetGens []sfGen) ([]Zone, error) {
+	ret := make([]Zone, 0)
+	var global []sfGen
+	for b := inst[instId].bagNdx; b < inst[instId+1].bagNdx && b+1 < len(ibag); b++ {
+		gens := sliceGens(igen, ibag[b], ibag[b+1])
+		sampleId, ok := findGen(gens, genSampleId)
+		if !ok {
+			if b == inst[instId].bagNdx {
+				global = gens
+			}
+			continue
+		}
+		if int(sampleId) >= len(samples) {
+			return nil, fmt.Errorf("sf2: %w: instrument %q uses sample %d", ErrBadSoundFont, inst[instId].name, sampleId)
+		}
+		gens = append(append([]sfGen{}, global...), gens...)
+
+		z := Zone{
+			KeyLo: 0, KeyHi: 127,
+			VelLo: 0, VelHi: 127,
+			Sample: samples[sampleId],
+		}
+		// Both the preset and the instrument can narrow down the ranges
+		for _, g := range [][]sfGen{presetGens, gens} {
+			if v, ok := findGen(g, genKeyRange); ok {
+				z.KeyLo, z.KeyHi = maxInt(z.KeyLo, int(v&0xFF)), minInt(z.KeyHi, int(v>>8))
+			}
+			if v, ok := findGen(g, genVelRange); ok {
+				z.VelLo, z.VelHi = maxInt(z.VelLo, int(v&0xFF)), minInt(z.VelHi, int(v>>8))
+			}
+		}
+
+		// Sample offsets, in sample points and in 32768-point chunks
+		s := &z.Sample
+		s.Start += genInt(gens, genStartOffset, 0) + 32768*genInt(gens, genStartCoarseOffset, 0)
+		s.End += genInt(gens, genEndOffset, 0) + 32768*genInt(gens, genEndCoarseOffset, 0)
+		s.LoopStart += genInt(gens, genLoopStartOffset, 0) + 32768*genInt(gens, genLoopStartCoarse, 0)
+		s.LoopEnd += genInt(gens, genLoopEndOffset, 0) + 32768*genInt(gens, genLoopEndCoarse, 0)
+		if s.Start < 0 || s.End > len(sf.samples) || s.Start >= s.End {
+			continue
+		}
+
+		z.RootKey = genInt(gens, genRootKey, -1)
+		if z.RootKey < 0 {
+			z.RootKey = s.OriginalKey
+		}
+		// Preset-level tuning, pan and attenuation add on top of the instrument's
+		z.Tune = float64(genInt(gens, genCoarseTune, 0)+genInt(presetGens, genCoarseTune, 0)) +
+			float64(genInt(gens, genFineTune, 0)+genInt(presetGens, genFineTune, 0)+s.Correction)/100
+		mode := genInt(gens, genSampleModes, 0)
+		z.Loop = (mode == 1 || mode == 3) && s.LoopEnd > s.LoopStart
+		z.Pan = float64(genInt(gens, genPan, 0)+genInt(presetGens, genPan, 0)) / 500
+		z.Attenuation = float64(genInt(gens, genAttenuation, 0)+genInt(presetGens, genAttenuation, 0)) / 10
+		z.Attack = genSeconds(gens, genAttackVolEnv)
+		z.Hold = genSeconds(gens, genHoldVolEnv)
+		z.Decay = genSeconds(gens, genDecayVolEnv)
+		// Sustain is how far down it drops, in centibels
+		z.Sustain = math.Pow(10, -float64(genInt(gens, genSustainVolEnv, 0))/200)
+		z.Release = genSeconds(gens, genReleaseVolEnv)
+
+		ret = append(ret, z)
+	}
+	return ret, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Find a preset; nil if the SoundFont doesn't have it
+func (sf *SoundFont) Preset(bank, program int) *Preset {
+	for i := range sf.Presets {
+		if sf.Presets[i].Bank == bank && sf.Presets[i].Program == program {
+			return &sf.Presets[i]
+		}
+	}
+	return nil
+}
+
+// Start a note with whatever sample fits; nil if there isn't one
+func (sf *SoundFont) newVoice(bank, program, key, velocity, sampleRate int) voice {
+	p := sf.Preset(bank, program)
+	if p == nil && bank != 0 && bank != 128 {
+		// Fall back to the General MIDI bank
+		p = sf.Preset(0, program)
+	}
+	if p == nil {
+		return nil
+	}
+
+	for _, z := range p.Zones {
+		if key < z.KeyLo || key > z.KeyHi || velocity < z.VelLo || velocity > z.VelHi {
+			continue
+		}
+		vel := float64(velocity) / 127
+		s := z.Sample
+		v := &sampleVoice{
+			data:      sf.samples[s.Start:s.End],
+			loop:      z.Loop,
+			loopStart: float64(s.LoopStart - s.Start),
+			loopEnd:   float64(s.LoopEnd - s.Start),
+			step:      float64(s.SampleRate) / float64(sampleRate) * math.Pow(2, (float64(key-z.RootKey)+z.Tune)/12),
+			gain:      vel * vel * math.Pow(10, -z.Attenuation/20),
+			pan:       z.Pan,
+			env: envelope{
+				attack:     z.Attack,
+				decay:      z.Hold + z.Decay,
+				sustain:    z.Sustain,
+				release:    z.Release,
+				sampleRate: float64(sampleRate),
+			},
+		}
+		if v.loopEnd > float64(len(v.data)) || v.loopStart < 0 {
+			v.loop = false
+		}
+		return v
+	}
+	return nil
+}
+
+type sampleVoice struct {
+	data               []int16
+	pos                float64
+	step               float64
+	loop               bool
+	loopStart, loopEnd float64
+	gain               float64
+	pan                float64
+	env                envelope
+	ended              bool
+}
+
+func (v *sampleVoice) render(out []float32, pitch float64) {
+	left := v.gain * math.Min(1, 1-v.pan)
+	right := v.gain * math.Min(1, 1+v.pan)
+	for i := 0; i+1 < len(out); i += 2 {
+		if v.ended {
+			return
+		}
+		// Linear interpolation between the two nearest sample points
+		idx := int(v.pos)
+		frac := v.pos - float64(idx)
+		a := float64(v.data[idx])
+		b := a
+		if idx+1 < len(v.data) {
+			b = float64(v.data[idx+1])
+		}
+		x := (a + (b-a)*frac) / 32768 * v.env.next()
+		out[i] += float32(x * left)
+		out[i+1] += float32(x * right)
+
+		v.pos += v.step * pitch
+		if v.loop && v.pos >= v.loopEnd {
+			v.pos -= v.loopEnd - v.loopStart
+		} else if v.pos >= float64(len(v.data)) {
+			v.ended = true
+		}
+	}
+}
+
+func (v *sampleVoice) release() {
+	v.env.startRelease()
+}
+
+func (v *sampleVoice) finished() bool {
+	return v.ended || v.env.done
+}
@@ -0,0 +1,426 @@
+package midi
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Channel 10 (9, counting from 0) is always drums
+const DrumChannel = 9
+
+// How many notes can sound at once, before the oldest ones get cut off
+const MaxVoices = 64
+
+// Synth turns MIDI messages into sound. Without a SoundFont, it fakes the
+// General MIDI instruments with plain oscillators; close enough for background music.
+type Synth struct {
+	SampleRate int
+	SoundFont  *SoundFont // Optional: used for any instrument it has
+	Gain       float64    // Overall volume, so a bunch of notes at once don't clip
+
+	channels [16]channel
+	voices   []*activeVoice
+	noise    *rand.Rand
+	buf      []float32 // Scratch space for rendering one voice at a time
+}
+
+type channel struct {
+	program    int
+	bank       int
+	volume     float64 // CC 7
+	expression float64 // CC 11
+	pan        float64 // CC 10, from -1 (left) to 1 (right)
+	sustain    bool    // CC 64
+	bend       float64 // Pitch bend, in semitones
+}
+
+// A voice makes the sound for one note
+type voice interface {
+	// Add the next len(out)/2 stereo frames into out; pitch is a multiplier, for pitch bends
+	render(out []float32, pitch float64)
+	release()
+	finished() bool
+}
+
+type activeVoice struct {
+	channel   int
+	key       int
+	held      bool // Key is down
+	sustained bool // Key is up, but the sustain pedal is holding it
+	voice
+}
+
+func NewSynth(sampleRate int) *Synth {
+	s := &Synth{
+		SampleRate: sampleRate,
+		Gain:       0.25,
+		noise:      rand.New(rand.NewSource(1)),
+	}
+	s.Reset()
+	return s
+}
+
+// Stop every note, and put all the channels back to their defaults
+func (s *Synth) Reset() {
+	s.voices = s.voices[:0]
+	for i := range s.channels {
+		s.channels[i] = channel{
+			volume:     100.0 / 127,
+			expression: 1,
+		}
+	}
+}
+
+// Handle a channel message; everything else gets ignored
+func (s *Synth) Send(e Event) {
+	if e.Status < 0x80 || e.Status >= 0xF0 {
+		return
+	}
+	ch := &s.channels[e.Channel()]
+	switch e.Kind() {
+	case 0x80:
+		s.noteOff(e.Channel(), int(e.Data1))
+	case 0x90:
+		if e.Data2 == 0 {
+			s.noteOff(e.Channel(), int(e.Data1))
+		} else {
+			s.noteOn(e.Channel(), int(e.Data1), int(e.Data2))
+		}
+	case 0xB0:
+		s.controlChange(e.Channel(), int(e.Data1), int(e.Data2))
+	case 0xC0:
+		ch.program = int(e.Data1)
+	case 0xE0:
+		// 14 bits, centered on 0x2000, for +/- 2 semitones
+		bend := int(e.Data2)<<7 | int(e.Data1)
+		ch.bend = float64(bend-0x2000) / 0x2000 * 2
+	}
+}
+
+func (s *Synth) noteOn(chNum, key, velocity int) {
+	ch := &s.channels[chNum]
+	var v voice
+	if s.SoundFont != nil {
+		bank := ch.bank
+		if chNum == DrumChannel {
+			bank = 128
+		}
+		v = s.SoundFont.newVoice(bank, ch.program, key, velocity, s.SampleRate)
+	}
+	if v == nil && chNum == DrumChannel {
+		v = newDrumVoice(key, velocity, s.SampleRate, s.noise)
+	} else if v == nil {
+		v = newOscVoice(ch.program, key, velocity, s.SampleRate)
+	}
+
+	if len(s.voices) >= MaxVoices {
+		s.voices = s.voices[1:]
+	}
+	s.voices = append(s.voices, &activeVoice{
+		channel: chNum,
+		key:     key,
+		held:    true,
+		voice:   v,
+	})
+}
+
+func (s *Synth) noteOff(chNum, key int) {
+	for _, v := range s.voices {
+		if v.channel == chNum && v.key == key && v.held {
+			v.held = false
+			if s.channels[chNum].sustain {
+				v.sustained = true
+			} else {
+				v.release()
+			}
+		}
+	}
+}
+
+func (s *Synth) controlChange(chNum, cc, value int) {
+	ch := &s.channels[chNum]
+	switch cc {
+	case 0:
+		ch.bank = value
+	case 7:
+		ch.volume = float64(value) / 127
+	case 10:
+		ch.pan = float64(value-64) / 64
+	case 11:
+		ch.expression = float64(value) / 127
+	case 64:
+		ch.sustain = value >= 64
+		if !ch.sustain {
+			for _, v := range s.voices {
+				if v.channel == chNum && v.sustained {
+					v.sustained = false
+					v.release()
+				}
+			}
+		}
+	case 120, 123:
+		// All sound off / all notes off
+		for _, v := range s.voices {
+			if v.channel == chNum {
+				v.held = false
+				v.sustained = false
+				v.release()
+			}
+		}
+	case 121:
+		// Reset all controllers
+		ch.volume, ch.expression, ch.pan, ch.sustain, ch.bend = 100.0/127, 1, 0, false, 0
+	}
+}
+
+// How many notes are still making noise
+func (s *Synth) Active() int {
+	return len(s.voices)
+}
+
+// Render fills out with stereo frames (left, right, left, right...) from -1 to 1
+func (s *Synth) Render(out []float32) {
+	for i := range out {
+		out[i] = 0
+	}
+	if len(s.buf) < len(out) {
+		s.buf = make([]float32, len(out))
+	}
+	buf := s.buf[:len(out)]
+
+	alive := s.voices[:0]
+	for _, v := range s.voices {
+		ch := &s.channels[v.channel]
+		for i := range buf {
+			buf[i] = 0
+		}
+		v.render(buf, math.Pow(2, ch.bend/12))
+
+		// Mix it in with the channel's volume and pan
+		gain := s.Gain * ch.volume * ch.expression
+		left := float32(gain * math.Min(1, 1-ch.pan))
+		right := float32(gain * math.Min(1, 1+ch.pan))
+		for i := 0; i+1 < len(out); i += 2 {
+			out[i] += buf[i] * left
+			out[i+1] += buf[i+1] * right
+		}
+
+		if !v.finished() {
+			alive = append(alive, v)
+		}
+	}
+	s.voices = alive
+}
+
+// MIDI note number to Hz: A4 (note 69) is 440
+func keyFrequency(key int) float64 {
+	return 440 * math.Pow(2, float64(key-69)/12)
+}
+
+// A basic ADSR volume envelope; times are in seconds
+type envelope struct {
+	attack, decay, sustain, release float64
+
+	sampleRate float64
+	time       float64 // Since the note started, or since it was released
+	level      float64
+	releasedAt float64 // Level when the note was released
+	released   bool
+	done       bool
+}
+
+func (e *envelope) next() float64 {
+	t := e.time
+	e.time += 1 / e.sampleRate
+	if e.done {
+		return 0
+	}
+
+	if e.released {
+		if e.release <= 0 || t >= e.release {
+			e.done = true
+			return 0
+		}
+		e.level = e.releasedAt * (1 - t/e.release)
+		return e.level
+	}
+
+	switch {
+	case t < e.attack:
+		e.level = t / e.attack
+	case t < e.attack+e.decay:
+		e.level = 1 - (1-e.sustain)*(t-e.attack)/e.decay
+	default:
+		e.level = e.sustain
+		if e.sustain <= 0 {
+			e.done = true
+		}
+	}
+	return e.level
+}
+
+func (e *envelope) startRelease() {
+	if !e.released {
+		e.released = true
+		e.releasedAt = e.level
+		e.time = 0
+	}
+}
+
+// What the built-in synth does for each family of 8 General MIDI programs
+type oscPreset struct {
+	wave                            func(phase float64) float64
+	attack, decay, sustain, release float64
+}
+
+func sine(p float64) float64 {
+	return math.Sin(2 * math.Pi * p)
+}
+
+func triangle(p float64) float64 {
+	return 4*math.Abs(p-math.Floor(p+0.5)) - 1
+}
+
+func saw(p float64) float64 {
+	return 2 * (p - math.Floor(p+0.5))
+}
+
+func square(p float64) float64 {
+	if p-math.Floor(p) < 0.5 {
+		return 0.7
+	}
+	return -0.7
+}
+
+// Sine with some overtones, for piano-ish and organ-ish sounds
+func bright(p float64) float64 {
+	return 0.6*sine(p) + 0.25*sine(2*p) + 0.15*sine(3*p)
+}
+
+var oscPresets = [16]oscPreset{
+	{bright, 0.005, 1.2, 0.2, 0.3},   // Piano
+	{sine, 0.002, 0.5, 0, 0.2},       // Chromatic percussion
+	{bright, 0.01, 0.1, 0.9, 0.1},    // Organ
+	{triangle, 0.005, 0.8, 0.2, 0.2}, // Guitar
+	{triangle, 0.005, 0.4, 0.6, 0.1}, // Bass
+	{saw, 0.08, 0.3, 0.8, 0.3},       // Strings
+	{saw, 0.1, 0.3, 0.8, 0.4},        // Ensemble
+	{saw, 0.03, 0.2, 0.7, 0.15},      // Brass
+	{square, 0.02, 0.2, 0.7, 0.1},    // Reed
+	{sine, 0.04, 0.2, 0.8, 0.15},     // Pipe
+	{square, 0.005, 0.1, 0.8, 0.1},   // Synth lead
+	{saw, 0.3, 0.5, 0.7, 0.8},        // Synth pad
+	{sine, 0.1, 0.5, 0.5, 0.5},       // Synth effects
+	{triangle, 0.005, 0.6, 0.3, 0.2}, // Ethnic
+	{sine, 0.001, 0.3, 0, 0.1},       // Percussive
+	{triangle, 0.05, 0.5, 0.3, 0.3},  // Sound effects
+}
+
+type oscVoice struct {
+	wave  func(float64) float64
+	freq  float64
+	phase float64
+	gain  float64
+	env   envelope
+}
+
+func newOscVoice(program, key, velocity, sampleRate int) *oscVoice {
+	p := oscPresets[(program/8)%16]
+	vel := float64(velocity) / 127
+	return &oscVoice{
+		wave: p.wave,
+		freq: keyFrequency(key),
+		gain: vel * vel,
+		env: envelope{
+			attack:     p.attack,
+			decay:      p.decay,
+			sustain:    p.sustain,
+			release:    p.release,
+			sampleRate: float64(sampleRate),
+		},
+	}
+}
+
+func (v *oscVoice) render(out []float32, pitch float64) {
+	step := v.freq * pitch / v.env.sampleRate
+	for i := 0; i+1 < len(out); i += 2 {
+		x := float32(v.wave(v.phase) * v.gain * v.env.next())
+		out[i] += x
+		out[i+1] += x
+		v.phase += step
+		if v.phase >= 1 {
+			v.phase -= math.Floor(v.phase)
+		}
+	}
+}
+
+func (v *oscVoice) release() {
+	v.env.startRelease()
+}
+
+func (v *oscVoice) finished() bool {
+	return v.env.done
+}
+
+// Drums are mostly noise, except for the kicks and toms, which are a falling sine
+type drumVoice struct {
+	tonal bool
+	freq  float64
+	phase float64
+	gain  float64
+	noise *rand.Rand
+	env   envelope
+}
+
+func newDrumVoice(key, velocity, sampleRate int, noise *rand.Rand) *drumVoice {
+	vel := float64(velocity) / 127
+	v := &drumVoice{
+		gain:  vel * vel,
+		noise: noise,
+		env: envelope{
+			attack:     0.001,
+			decay:      0.15,
+			sampleRate: float64(sampleRate),
+		},
+	}
+	switch {
+	case key == 35 || key == 36:
+		// Bass drum
+		v.tonal, v.freq, v.env.decay = true, 60, 0.25
+	case key == 41 || key == 43 || key == 45 || key == 47 || key == 48 || key == 50:
+		// Toms, low to high
+		v.tonal, v.freq, v.env.decay = true, 80+float64(key-41)*15, 0.3
+	case key == 49 || key == 51 || key == 52 || key == 55 || key == 57 || key == 59:
+		// Cymbals ring out longer
+		v.env.decay = 0.8
+		v.gain *= 0.5
+	case key == 42 || key == 44:
+		// Closed hi-hats are short
+		v.env.decay = 0.05
+		v.gain *= 0.5
+	}
+	return v
+}
+
+func (v *drumVoice) render(out []float32, pitch float64) {
+	for i := 0; i+1 < len(out); i += 2 {
+		var x float64
+		if v.tonal {
+			// Drop the pitch as the note goes on
+			x = sine(v.phase)
+			v.phase += v.freq * (1 + 2*(1-v.env.time/v.env.decay)) / v.env.sampleRate
+		} else {
+			x = v.noise.Float64()*2 - 1
+		}
+		y := float32(x * v.gain * v.env.next())
+		out[i] += y
+		out[i+1] += y
+	}
+}
+
+// Drums just play out, whether or not the key is still down
+func (v *drumVoice) release() {}
+
+func (v *drumVoice) finished() bool {
+	return v.env.done
+}
@@ -0,0 +1,95 @@
+// Package rng is where the game's random numbers come from. It's seeded, so the same seed gets the
+// same world (and the same rolls) back, and its state is out in the open, so it can go in a save game.
+// It doesn't need ebiten, so headless tools like cmd/rendermap can rebuild a world from its seed.
+package rng
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"time"
+)
+
+// One stream of numbers: SplitMix64, which is quick, and only needs the one number of state
+type Stream struct {
+	State uint64
+}
+
+func (s *Stream) Uint64() uint64 {
+	s.State += 0x9e3779b97f4a7c15
+	z := s.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Random int from 0 to (n - 1); n has to be more than 0
+func (s *Stream) Intn(n int) int {
+	if n <= 0 {
+		panic(fmt.Sprintf("rng: Intn(%d)", n))
+	}
+	// Throw away the few values at the very top that would make the low numbers come up more often
+	un := uint64(n)
+	skip := (math.MaxUint64%un + 1) % un
+	for {
+		if v := s.Uint64(); v <= math.MaxUint64-skip {
+			return int(v % un)
+		}
+	}
+}
+
+// Random int from 1 to sides, like rolling a die
+func (s *Stream) Roll(sides int) int {
+	return s.Intn(sides) + 1
+}
+
+// All the streams the game uses, from one seed. Each one only moves along when it gets used,
+// so e.g. a critter wandering about doesn't change what the scripts roll.
+type Source struct {
+	Seed     int64
+	Worldgen Stream
+	AI       Stream
+	Scripts  Stream
+}
+
+func New(seed int64) *Source {
+	return &Source{
+		Seed:     seed,
+		Worldgen: newStream(seed, "worldgen"),
+		AI:       newStream(seed, "ai"),
+		Scripts:  newStream(seed, "scripts"),
+	}
+}
+
+// Each stream starts from the seed mixed up with its name, so they don't follow each other around
+func newStream(seed int64, name string) Stream {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	s := Stream{State: uint64(seed) ^ h.Sum64()}
+	s.Uint64()
+	return s
+}
+
+// A new seed, off the clock; it's kept to 9 digits, so it's easy to read out to someone.
+// It's never 0, since -seed 0 means "pick one", and then the seed couldn't be played again.
+func NewSeed() int64 {
+	return time.Now().UnixNano()%999999999 + 1
+}
+
+// Write the seed and where each stream is up to out as JSON, for a save game
+func (s *Source) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(s)
+}
+
+// Read back a Source written by Save; it carries on exactly where it left off
+func Load(r io.Reader) (*Source, error) {
+	s := &Source{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("loading random state: %w", err)
+	}
+	return s, nil
+}
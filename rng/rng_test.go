@@ -0,0 +1,44 @@
+package rng
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewSeed(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		if s := NewSeed(); s <= 0 || s > 999999999 {
+			t.Fatalf("NewSeed gave %d, want 1 to 999999999", s)
+		}
+	}
+}
+
+func TestSameSeed(t *testing.T) {
+	a, b := New(1234), New(1234)
+	// Other streams getting used mustn't change what the scripts roll
+	b.AI.Intn(10)
+	b.Worldgen.Intn(10)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Scripts.Roll(6), b.Scripts.Roll(6); x != y {
+			t.Fatalf("roll %d: got %d and %d from the same seed", i, x, y)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	a := New(99)
+	a.Scripts.Roll(20)
+	var buf bytes.Buffer
+	if err := a.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if x, y := a.Scripts.Roll(20), b.Scripts.Roll(20); x != y {
+			t.Fatalf("roll %d after loading: got %d, want %d", i, y, x)
+		}
+	}
+}
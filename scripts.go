@@ -0,0 +1,94 @@
+package main
+
+// Zone scripts as text: `export scripts` disassembles every zone's action triggers, one script file per zone,
+// and -scripts assembles them back in
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/MasterShizzle/goda-stories/gosoh"
+	"github.com/MasterShizzle/goda-stories/iact"
+)
+
+const scriptExt = ".iact"
+
+func runExportScripts(args []string) {
+	fs := flag.NewFlagSet("export scripts", flag.ExitOnError)
+	outDir := fs.String("o", "scripts", "folder to write the zone_NNN"+scriptExt+" files to")
+	fs.Parse(args)
+
+	data := readDataFile()
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	// Tile names come from TNAM, same as in the game
+	gosoh.Items = data.Items
+	names := func(tNum int) string {
+		if name := gosoh.GetItemName(tNum); name != "UNKNOWN" {
+			return name
+		}
+		return ""
+	}
+
+	count := 0
+	for i := range data.Zones {
+		z := &data.Zones[i]
+		if len(z.ActionTriggers) == 0 {
+			continue
+		}
+		fileName := filepath.Join(*outDir, fmt.Sprintf("zone_%03d%s", z.Id, scriptExt))
+		f, err := os.Create(fileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := iact.Disassemble(f, z, names); err != nil {
+			log.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
+		}
+		count++
+	}
+	fmt.Printf("[ExportScripts] Wrote %d zone scripts to %s\n", count, *outDir)
+}
+
+// Replace the action triggers of each zone that has a zone_NNN.iact file in dir
+func loadZoneScripts(dir string, zones []gosoh.ZoneInfo, tileCount int) error {
+	files, err := filepath.Glob(filepath.Join(dir, "zone_*"+scriptExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, fileName := range files {
+		base := filepath.Base(fileName)
+		zId, err := strconv.Atoi(base[len("zone_") : len(base)-len(scriptExt)])
+		if err != nil || zId < 0 || zId >= len(zones) {
+			return fmt.Errorf("%s: no zone with that number", fileName)
+		}
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		triggers, err := iact.Assemble(f, tileCount)
+		f.Close()
+		if err != nil {
+			// Each of the assembler's errors on its own line, with the file name, like a compiler
+			if errs, ok := err.(iact.ErrorList); ok {
+				for _, e := range errs {
+					fmt.Fprintf(os.Stderr, "%s:%d:%d: %v\n", fileName, e.Line, e.Col, e.Err)
+				}
+				return fmt.Errorf("%s: %d errors", fileName, len(errs))
+			}
+			return fmt.Errorf("%s: %w", fileName, err)
+		}
+		zones[zId].ActionTriggers = triggers
+		fmt.Printf("[loadZoneScripts] Loaded %d scripts for zone %03d from %s\n", len(triggers), zId, fileName)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/MasterShizzle/goda-stories/gosoh"
 )
 
@@ -9,6 +11,8 @@ type GameWorld struct {
 	Name        string
 	SubAreas    []*gosoh.MapArea
 	CurrentArea int
+	Planet      int                // Which SubArea is the planet; -1 if we couldn't make one
+	Puzzles     *gosoh.PuzzleChain // What has to be done on the planet, in order
 }
 
 // Coordinates of the Viewport; all measurements are in pixels
@@ -19,22 +23,71 @@ type ViewCoords struct {
 	Height float64
 }
 
-func NewWorld() *GameWorld {
+// Make Dagobah, and a planet to go with it. Without one, the game can still go on,
+// unless opts.Strict says the planet has to be exactly what was asked for.
+func NewWorld(opts NewGameOptions) (*GameWorld, error) {
 	gw := GameWorld{
-		Name: "Goda Stories",
+		Name:   "Goda Stories",
+		Planet: -1,
 	}
 	gw.SubAreas = make([]*gosoh.MapArea, 0)
 
-	// Make a new Overworld
 	// Place the player on Dagobah
-	world := gosoh.NewOverworld(10, 10)
-	gw.SubAreas = append(gw.SubAreas, world)
-	gw.CurrentArea = world.Id
-	world.PrintMap()
+	dago := gosoh.NewDagobah()
+	dago.Id = len(gw.SubAreas)
+	gw.SubAreas = append(gw.SubAreas, dago)
+	gw.CurrentArea = dago.Id
+	dago.PrintMap()
+
+	// Make a new Overworld
+	// TODO: fly there from Dagobah in the X-Wing
+	planet, chain, err := gosoh.NewOverworld(opts.planetOptions())
+	if err != nil && opts.Strict {
+		return nil, fmt.Errorf("can't make that planet: %w", err)
+	} else if err != nil {
+		fmt.Printf("[NewWorld] No planet this time: %v\n", err)
+		return &gw, nil
+	}
+	planet.Id = len(gw.SubAreas)
+	gw.SubAreas = append(gw.SubAreas, planet)
+	gw.Planet = planet.Id
+	gw.Puzzles = chain
+	planet.PrintMap()
+	for i, step := range chain.Steps {
+		fmt.Printf("[NewWorld] Puzzle step %d: %s\n", i+1, step.ToString())
+	}
 
-	return &gw
+	return &gw, nil
 }
 
 func (gw *GameWorld) GetCurrentArea() *gosoh.MapArea {
 	return gw.SubAreas[gw.CurrentArea]
 }
+
+// Move the player to the given zone (x,y being tile coords in it), for scripts' GoToZone.
+// If an area we've already made has that zone, go back there; otherwise it gets an area of its own.
+func (gw *GameWorld) GoToZone(zoneId, x, y int) {
+	for i, area := range gw.SubAreas {
+		for zx := range area.Zones {
+			for zy, z := range area.Zones[zx] {
+				if z != nil && z.Id == zoneId {
+					gw.enterArea(i, zx*18+x, zy*18+y)
+					return
+				}
+			}
+		}
+	}
+
+	area := gosoh.NewMapArea(1, 1)
+	area.Id = len(gw.SubAreas)
+	area.AddZoneToArea(zoneId, 0, 0)
+	gw.SubAreas = append(gw.SubAreas, &area)
+	gw.enterArea(area.Id, x, y)
+}
+
+func (gw *GameWorld) enterArea(areaId, x, y int) {
+	gw.CurrentArea = areaId
+	a := gw.GetCurrentArea()
+	gosoh.SpawnZoneActors(a)
+	gosoh.SetPlayerTile(a, x, y)
+}
@@ -0,0 +1,283 @@
+package worldgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+// Puzzle chain:
+// - the puzzles get shared out between the regions in order, with each region's gate after its puzzles,
+//   and the FinalDestination last of all
+// - then the items get picked from the goal backwards: each puzzle hands out what the next one needs,
+//   and its NPC asks for something new in return
+// - the first puzzle is an ItemForTask, so it doesn't need anything; the rest are ItemForItem or ItemForTool
+// - a gate just needs to see whatever the puzzle before it handed out
+
+// Where each step goes, in order; free loses the cells that get used
+func (g *generator) placeSteps(free [][]cell) ([]PuzzleStep, error) {
+	n := g.opts.PuzzleSteps
+	regions := len(free)
+	steps := make([]PuzzleStep, 0)
+	carry := 0
+	for r := range free {
+		count := n/regions + carry
+		if r < n%regions {
+			count++
+		}
+		if r == regions-1 {
+			count++ // The goal
+		}
+		carry = 0
+		if count > len(free[r]) {
+			// No room here, so they'll have to go in the next region
+			carry = count - len(free[r])
+			count = len(free[r])
+		}
+		for i := 0; i < count; i++ {
+			c := free[r][i]
+			steps = append(steps, PuzzleStep{X: c.x, Y: c.y, Puzzle: -1, Needs: -1, Gives: -1})
+		}
+		free[r] = free[r][count:]
+
+		if r < len(g.gates) {
+			gt := g.gates[r]
+			steps = append(steps, PuzzleStep{X: gt.at.x, Y: gt.at.y, Type: "GateTo" + gt.dir.name, Puzzle: -1, Needs: -1, Gives: -1})
+		}
+	}
+	if carry > 0 {
+		return nil, fmt.Errorf("%w: no room for %d puzzles on a %dx%d planet", ErrTooSmall, n, g.opts.Width, g.opts.Height)
+	}
+	steps[len(steps)-1].Type = "FinalDestination"
+	return steps, nil
+}
+
+// Pick the zones, puzzles and items for each step
+func (g *generator) fillChain(steps []PuzzleStep, goal *dta.PuzzleInfo) error {
+	first := -1
+	for i, s := range steps {
+		if s.Type == "" {
+			first = i
+			break
+		}
+	}
+
+	want := itemOrNone(goal.LockItemId)
+	g.usedItems[want] = true
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := &steps[i]
+		switch {
+		case s.Type == "FinalDestination":
+			s.ZoneId = g.pickZone(want, isType(s.Type))
+			s.Puzzle = goal.Id
+			s.Needs = want
+			s.Gives = itemOrNone(goal.RewardItemId)
+		case strings.HasPrefix(s.Type, "GateTo"):
+			s.ZoneId = g.pickZone(-1, isType(s.Type))
+		case i == first:
+			s.Type = "ItemForTask"
+			s.ZoneId = g.pickZone(want, isType(s.Type))
+			s.Puzzle = g.pickTask(want)
+			s.Gives = want
+		default:
+			types := make([]string, 0)
+			for _, t := range []string{"ItemForItem", "ItemForTool"} {
+				if g.countZones(isType(t)) > 0 {
+					types = append(types, t)
+				}
+			}
+			if len(types) == 0 {
+				return fmt.Errorf("%w: no ItemForItem or ItemForTool zones left on a %s planet", ErrNoZones, g.biome)
+			}
+			s.Type = types[g.rnd.Intn(len(types))]
+			s.ZoneId = g.pickZone(want, isType(s.Type))
+			p := g.pickTrade(want, s.Type == "ItemForTool")
+			if p == nil {
+				return fmt.Errorf("%w: no ItemForItem puzzles left for step %d", ErrNoPuzzles, i)
+			}
+			s.Puzzle = p.Id
+			s.Needs = p.LockItemId
+			s.Gives = want
+			want = s.Needs
+		}
+		if s.ZoneId < 0 {
+			return fmt.Errorf("%w: no %s zones left on a %s planet", ErrNoZones, s.Type, g.biome)
+		}
+	}
+
+	have := -1
+	for i := range steps {
+		if strings.HasPrefix(steps[i].Type, "GateTo") {
+			steps[i].Needs = have
+		} else if steps[i].Gives >= 0 {
+			have = steps[i].Gives
+		}
+	}
+	return nil
+}
+
+// A puzzle for an NPC who hands over want and asks for something we haven't used yet.
+// ItemForTool zones would rather have a puzzle about a tool.
+func (g *generator) pickTrade(want int, tool bool) *dta.PuzzleInfo {
+	all := make([]*dta.PuzzleInfo, 0)
+	tools := make([]*dta.PuzzleInfo, 0)
+	for i := range g.puzzles {
+		p := &g.puzzles[i]
+		item := itemOrNone(p.LockItemId)
+		if p.Type != "ItemForItem" || g.usedPuzzles[p.Id] || item < 0 || g.usedItems[item] {
+			continue
+		}
+		all = append(all, p)
+		if p.ItemType == "Tool" {
+			tools = append(tools, p)
+		}
+	}
+	if tool && len(tools) > 0 {
+		all = tools
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	p := all[g.rnd.Intn(len(all))]
+	g.usedPuzzles[p.Id] = true
+	g.usedItems[p.LockItemId] = true
+	return p
+}
+
+// A task puzzle for the first step, ideally one that's about the item it hands out; -1 if there aren't any
+func (g *generator) pickTask(want int) int {
+	all := make([]int, 0)
+	matching := make([]int, 0)
+	for _, p := range g.puzzles {
+		if (p.Type != "ItemForTask" && p.Type != "ItemForTask2") || g.usedPuzzles[p.Id] {
+			continue
+		}
+		all = append(all, p.Id)
+		if p.LockItemId == want {
+			matching = append(matching, p.Id)
+		}
+	}
+	if len(matching) > 0 {
+		all = matching
+	}
+	if len(all) == 0 {
+		return -1
+	}
+	id := all[g.rnd.Intn(len(all))]
+	g.usedPuzzles[id] = true
+	return id
+}
+
+// Put one more special zone in region r, if there's room and a zone for it
+func (g *generator) placeExtra(p *Planet, free [][]cell, r int, match func(*dta.ZoneInfo) bool) {
+	if len(free[r]) == 0 {
+		return
+	}
+	z := g.pickZone(-1, match)
+	if z < 0 {
+		return
+	}
+	c := free[r][0]
+	free[r] = free[r][1:]
+	p.Zones[c.x][c.y] = z
+}
+
+// An unused zone on this planet that matches, preferring ones that can hand out want.
+// Returns its ID, or -1 if there aren't any.
+func (g *generator) pickZone(want int, match func(*dta.ZoneInfo) bool) int {
+	all := make([]int, 0)
+	preferred := make([]int, 0)
+	for i := range g.zones {
+		z := &g.zones[i]
+		if g.usedZones[i] || z.Biome != g.biome || !g.usable(z) || !match(z) {
+			continue
+		}
+		all = append(all, i)
+		if want >= 0 && hasItem(z.RewardItems, want) {
+			preferred = append(preferred, i)
+		}
+	}
+	if len(preferred) > 0 {
+		all = preferred
+	}
+	if len(all) == 0 {
+		return -1
+	}
+	id := all[g.rnd.Intn(len(all))]
+	g.usedZones[id] = true
+	return id
+}
+
+// A Plain zone for the scenery; once they've all been used, they start getting used again
+func (g *generator) pickFiller() int {
+	if z := g.pickZone(-1, isFiller); z >= 0 {
+		return z
+	}
+	all := make([]int, 0)
+	for i := range g.zones {
+		if z := &g.zones[i]; z.Biome == g.biome && g.usable(z) && isFiller(z) {
+			all = append(all, i)
+		}
+	}
+	if len(all) == 0 {
+		return -1
+	}
+	return all[g.rnd.Intn(len(all))]
+}
+
+// How many unused zones on this planet match
+func (g *generator) countZones(match func(*dta.ZoneInfo) bool) int {
+	n := 0
+	for i := range g.zones {
+		if z := &g.zones[i]; !g.usedZones[i] && z.Biome == g.biome && g.usable(z) && match(z) {
+			n++
+		}
+	}
+	return n
+}
+
+// Only full-sized overworld zones can go on a planet
+func (g *generator) usable(z *dta.ZoneInfo) bool {
+	return z.IsOverworld && z.Width == zoneSize && z.Height == zoneSize
+}
+
+func isType(t string) func(*dta.ZoneInfo) bool {
+	return func(z *dta.ZoneInfo) bool {
+		return z.Type == t
+	}
+}
+
+// Teleporters are Plain zones, with a TeleportSpot
+func isTeleporter(z *dta.ZoneInfo) bool {
+	if z.Type != "Plain" {
+		return false
+	}
+	for _, h := range z.Hotspots {
+		if h.Type == dta.TeleportSpot {
+			return true
+		}
+	}
+	return false
+}
+
+func isFiller(z *dta.ZoneInfo) bool {
+	return z.Type == "Plain" && !isTeleporter(z)
+}
+
+func hasItem(items []int, item int) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Item IDs of 0 or 65535 mean there isn't one
+func itemOrNone(id int) int {
+	if id <= 0 || id == 65535 {
+		return -1
+	}
+	return id
+}
@@ -0,0 +1,140 @@
+package worldgen
+
+// Layout: grow a blob of zones out from the landing spot, then for each gate, find a spot on the
+// edge with nothing around it, put the gate there, and grow the next region out the far side.
+// A region never touches another one, so the only way between them is through the gate.
+
+func (g *generator) layout(start cell) {
+	w, h := g.opts.Width, g.opts.Height
+	g.region = make([][]int, w)
+	for x := range g.region {
+		g.region[x] = make([]int, h)
+		for y := range g.region[x] {
+			g.region[x][y] = noRegion
+		}
+	}
+
+	wantGates := g.opts.PuzzleSteps / 3
+	total := w * h * fillPercent / 100
+	size := total / (wantGates + 1)
+
+	g.region[start.x][start.y] = 0
+	g.regions = [][]cell{g.grow(0, []cell{start}, size)}
+	reserved := make(map[string]int) // Gate zones already spoken for, by direction
+	for len(g.gates) < wantGates {
+		gt, seed, ok := g.findGate(len(g.regions)-1, reserved)
+		if !ok {
+			break
+		}
+		reserved[gt.dir.name]++
+		g.region[gt.at.x][gt.at.y] = gateRegion
+		g.gates = append(g.gates, gt)
+
+		r := len(g.regions)
+		g.region[seed.x][seed.y] = r
+		g.regions = append(g.regions, g.grow(r, []cell{seed}, size))
+	}
+
+	// If we ran out of room for gates, the last region gets the leftovers
+	filled := len(g.gates)
+	for _, cells := range g.regions {
+		filled += len(cells)
+	}
+	last := len(g.regions) - 1
+	g.regions[last] = g.grow(last, g.regions[last], len(g.regions[last])+total-filled)
+}
+
+// Add cells to region r until it's size cells big, or there's nowhere left to go.
+// Cells next to lots of the region come up more often, so it grows in a blob rather than tendrils.
+func (g *generator) grow(r int, cells []cell, size int) []cell {
+	for len(cells) < size {
+		candidates := make([]cell, 0)
+		for _, c := range cells {
+			for _, d := range directions {
+				if n := (cell{c.x + d.dx, c.y + d.dy}); g.canJoin(n, r) {
+					candidates = append(candidates, n)
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		n := candidates[g.rnd.Intn(len(candidates))]
+		g.region[n.x][n.y] = r
+		cells = append(cells, n)
+	}
+	return cells
+}
+
+// An empty cell can join region r if it doesn't touch anything else
+func (g *generator) canJoin(c cell, r int) bool {
+	if !g.inside(c) || g.region[c.x][c.y] != noRegion {
+		return false
+	}
+	for _, d := range directions {
+		n := cell{c.x + d.dx, c.y + d.dy}
+		if g.inside(n) && g.region[n.x][n.y] != noRegion && g.region[n.x][n.y] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// Somewhere off the edge of region r for a gate to go: two empty cells in a row, the gate and then
+// the next region's first cell, with nothing else around them. There has to be a gate zone left
+// that faces that way, too.
+func (g *generator) findGate(r int, reserved map[string]int) (gate, cell, bool) {
+	type candidate struct {
+		gt   gate
+		seed cell
+	}
+	candidates := make([]candidate, 0)
+	for _, d := range directions {
+		if g.countZones(isType("GateTo"+d.name)) <= reserved[d.name] {
+			continue
+		}
+		for _, c := range g.regions[r] {
+			at := cell{c.x + d.dx, c.y + d.dy}
+			seed := cell{at.x + d.dx, at.y + d.dy}
+			if g.inside(seed) && g.isolated(at, c) && g.isolated(seed, at) {
+				candidates = append(candidates, candidate{gate{at, d}, seed})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return gate{}, cell{}, false
+	}
+	pick := candidates[g.rnd.Intn(len(candidates))]
+	return pick.gt, pick.seed, true
+}
+
+// Is c empty, with nothing next to it but from?
+func (g *generator) isolated(c, from cell) bool {
+	if !g.inside(c) || g.region[c.x][c.y] != noRegion {
+		return false
+	}
+	for _, d := range directions {
+		n := cell{c.x + d.dx, c.y + d.dy}
+		if n != from && g.inside(n) && g.region[n.x][n.y] != noRegion {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *generator) inside(c cell) bool {
+	return c.x >= 0 && c.y >= 0 && c.x < g.opts.Width && c.y < g.opts.Height
+}
+
+// 0 to n-1, shuffled
+func (g *generator) perm(n int) []int {
+	ret := make([]int, n)
+	for i := range ret {
+		ret[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := g.rnd.Intn(i + 1)
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret
+}
@@ -0,0 +1,307 @@
+// Package worldgen lays out a planet for the overworld, following the original game's rules as near
+// as we can tell: a blob of zones around where the X-Wing lands, split into regions by gates at the
+// chokepoints, with a chain of puzzles running through them to the FinalDestination.
+// Like dta, it doesn't need ebiten, so tools can use it headless.
+package worldgen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+)
+
+var (
+	ErrTooSmall  = errors.New("planet too small")
+	ErrNoZones   = errors.New("not enough zones")
+	ErrNoPuzzles = errors.New("not enough puzzles")
+)
+
+// The planets there are to pick from; Dagobah's "swamp" is only ever Dagobah
+var Biomes = []string{"desert", "snow", "forest"}
+
+// World sizes to pick from for a new game, like the original's: how many zones across (and down)
+// the planet is. Medium is the original's 10x10.
+var Sizes = map[string]int{
+	"small":  8,
+	"medium": 10,
+	"large":  12,
+}
+
+// How much of the grid gets zones; the rest is the edge of the world
+const fillPercent = 55
+
+// How many times to lay the planet out before giving up
+const layoutTries = 10
+
+// Zones on a planet are always 18x18 tiles
+const zoneSize = 18
+
+type Options struct {
+	Width       int // In zones
+	Height      int
+	PuzzleSteps int    // How many puzzles there are before the FinalDestination
+	Biome       string // One of Biomes; "" picks one
+	MainQuest   int    // Id of the MainQuest puzzle (one of the 15 in PUZ2) to use as the goal; -1 picks one
+}
+
+// Anything that can pick a number from 0 to n-1, like a *math/rand.Rand
+type Rand interface {
+	Intn(n int) int
+}
+
+// One step of the puzzle chain: a zone, what it takes, and what it hands out
+type PuzzleStep struct {
+	ZoneId int
+	X      int // Where the zone is on the planet
+	Y      int
+	Type   string // The zone's type: ItemForTask, ItemForItem, ItemForTool, GateTo*, or FinalDestination
+	Puzzle int    // Id of the puzzle that goes with it; -1 for none
+	Needs  int    // Item it takes (gates only have to see it); -1 for none
+	Gives  int    // Item it hands out; -1 for none
+}
+
+type PuzzleChain struct {
+	Goal  int          // Id of the MainQuest puzzle
+	Steps []PuzzleStep // In the order they have to be done
+}
+
+type Planet struct {
+	Width  int
+	Height int
+	Biome  string
+	Zones  [][]int // Zone IDs as [x][y]; -1 where there's nothing
+	StartX int     // The HomeBase zone, where the X-Wing lands
+	StartY int
+	Chain  PuzzleChain
+}
+
+// How many puzzles a w x h planet gets, unless told otherwise: 6 for the usual 10x10
+func DefaultSteps(w, h int) int {
+	steps := w * h / 16
+	if steps < 1 {
+		return 1
+	}
+	return steps
+}
+
+// Ids of the puzzles that can be the goal, for Options.MainQuest
+func MainQuests(puzzles []dta.PuzzleInfo) []int {
+	ret := make([]int, 0)
+	for _, p := range puzzles {
+		if p.Type == "MainQuest" {
+			ret = append(ret, p.Id)
+		}
+	}
+	return ret
+}
+
+func (s PuzzleStep) ToString() string {
+	out := fmt.Sprintf("%s zone %03d at (%d,%d)", s.Type, s.ZoneId, s.X, s.Y)
+	if s.Puzzle >= 0 {
+		out += fmt.Sprintf(", puzzle %d", s.Puzzle)
+	}
+	if s.Needs >= 0 {
+		out += fmt.Sprintf(", needs item %d", s.Needs)
+	}
+	if s.Gives >= 0 {
+		out += fmt.Sprintf(", gives item %d", s.Gives)
+	}
+	return out
+}
+
+type cell struct {
+	x, y int
+}
+
+type direction struct {
+	dx, dy int
+	name   string
+}
+
+var directions = []direction{
+	{0, -1, "North"},
+	{0, 1, "South"},
+	{1, 0, "East"},
+	{-1, 0, "West"},
+}
+
+// Marks in generator.region
+const (
+	noRegion   = -1
+	gateRegion = -2
+)
+
+type gate struct {
+	at  cell
+	dir direction
+}
+
+type generator struct {
+	zones   []dta.ZoneInfo
+	puzzles []dta.PuzzleInfo
+	opts    Options
+	rnd     Rand
+	biome   string
+
+	region      [][]int  // Which region each cell's in, as [x][y]
+	regions     [][]cell // Each region's cells
+	gates       []gate   // gates[i] leads into regions[i+1]
+	usedZones   map[int]bool
+	usedPuzzles map[int]bool
+	usedItems   map[int]bool
+}
+
+// Lay out a planet from the given zones and puzzles
+func Generate(zones []dta.ZoneInfo, puzzles []dta.PuzzleInfo, opts Options, rnd Rand) (*Planet, error) {
+	if opts.Width < 3 || opts.Height < 3 {
+		return nil, fmt.Errorf("%w: %dx%d, and it has to be at least 3x3", ErrTooSmall, opts.Width, opts.Height)
+	}
+	if opts.PuzzleSteps < 1 {
+		opts.PuzzleSteps = 1
+	}
+	g := &generator{
+		zones:       zones,
+		puzzles:     puzzles,
+		opts:        opts,
+		rnd:         rnd,
+		usedZones:   make(map[int]bool),
+		usedPuzzles: make(map[int]bool),
+		usedItems:   make(map[int]bool),
+	}
+
+	if err := g.pickBiome(); err != nil {
+		return nil, err
+	}
+	goal, err := g.pickGoal()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Planet{
+		Width:  opts.Width,
+		Height: opts.Height,
+		Biome:  g.biome,
+		Zones:  make([][]int, opts.Width),
+		StartX: opts.Width / 2,
+		StartY: opts.Height / 2,
+	}
+	for x := range p.Zones {
+		p.Zones[x] = make([]int, opts.Height)
+		for y := range p.Zones[x] {
+			p.Zones[x][y] = -1
+		}
+	}
+
+	// Regions can box each other in and leave no room for the puzzles, which small planets
+	// do more often, so have a few goes at it
+	var free [][]cell
+	var steps []PuzzleStep
+	for try := 0; try < layoutTries; try++ {
+		g.gates = nil
+		g.layout(cell{p.StartX, p.StartY})
+		// Everywhere in each region that's up for grabs, in a random order; the landing spot's already taken
+		free = make([][]cell, len(g.regions))
+		for r, cells := range g.regions {
+			free[r] = make([]cell, 0, len(cells))
+			for _, i := range g.perm(len(cells)) {
+				if c := cells[i]; c.x != p.StartX || c.y != p.StartY {
+					free[r] = append(free[r], c)
+				}
+			}
+		}
+
+		if steps, err = g.placeSteps(free); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := g.fillChain(steps, goal); err != nil {
+		return nil, err
+	}
+	for _, s := range steps {
+		p.Zones[s.X][s.Y] = s.ZoneId
+	}
+	p.Chain = PuzzleChain{Goal: goal.Id, Steps: steps}
+
+	// The rest of the special zones, if there's room: where the Force is, and a pair of teleporters,
+	// one near the start and one out by the goal
+	home := g.pickZone(-1, isType("HomeBase"))
+	if home < 0 {
+		home = g.pickFiller()
+	}
+	p.Zones[p.StartX][p.StartY] = home
+	g.placeExtra(p, free, 0, isType("FindTheForce"))
+	g.placeExtra(p, free, 0, isTeleporter)
+	g.placeExtra(p, free, len(free)-1, isTeleporter)
+
+	// Everything else is just scenery
+	for _, cells := range free {
+		for _, c := range cells {
+			z := g.pickFiller()
+			if z < 0 {
+				return nil, fmt.Errorf("%w: no Plain %s zones to fill in with", ErrNoZones, g.biome)
+			}
+			p.Zones[c.x][c.y] = z
+		}
+	}
+	return p, nil
+}
+
+// Use the biome we were told to, or else any one that has somewhere to finish up
+func (g *generator) pickBiome() error {
+	hasGoal := func(biome string) bool {
+		for i := range g.zones {
+			z := &g.zones[i]
+			if z.Biome == biome && g.usable(z) && z.Type == "FinalDestination" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if g.opts.Biome != "" {
+		if !hasGoal(g.opts.Biome) {
+			return fmt.Errorf("%w: no FinalDestination zones on a %q planet", ErrNoZones, g.opts.Biome)
+		}
+		g.biome = g.opts.Biome
+		return nil
+	}
+	candidates := make([]string, 0)
+	for _, b := range Biomes {
+		if hasGoal(b) {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("%w: no FinalDestination zones on any planet", ErrNoZones)
+	}
+	g.biome = candidates[g.rnd.Intn(len(candidates))]
+	return nil
+}
+
+func (g *generator) pickGoal() (*dta.PuzzleInfo, error) {
+	quests := make([]*dta.PuzzleInfo, 0)
+	for i := range g.puzzles {
+		p := &g.puzzles[i]
+		if p.Type != "MainQuest" {
+			continue
+		}
+		if p.Id == g.opts.MainQuest {
+			g.usedPuzzles[p.Id] = true
+			return p, nil
+		}
+		quests = append(quests, p)
+	}
+	if g.opts.MainQuest >= 0 {
+		return nil, fmt.Errorf("%w: there's no MainQuest puzzle %d", ErrNoPuzzles, g.opts.MainQuest)
+	}
+	if len(quests) == 0 {
+		return nil, fmt.Errorf("%w: no MainQuest puzzles", ErrNoPuzzles)
+	}
+	p := quests[g.rnd.Intn(len(quests))]
+	g.usedPuzzles[p.Id] = true
+	return p, nil
+}
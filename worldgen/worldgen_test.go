@@ -0,0 +1,155 @@
+package worldgen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MasterShizzle/goda-stories/dta"
+	"github.com/MasterShizzle/goda-stories/rng"
+)
+
+// Plenty of snow zones of every type, and puzzles for them, so any size of planet can be made
+func synthData() ([]dta.ZoneInfo, []dta.PuzzleInfo) {
+	zones := make([]dta.ZoneInfo, 0)
+	add := func(zoneType string, n int) {
+		for i := 0; i < n; i++ {
+			zones = append(zones, dta.ZoneInfo{Id: len(zones), Type: zoneType, Biome: "snow", Width: 18, Height: 18, IsOverworld: true})
+		}
+	}
+	add("FinalDestination", 3)
+	add("HomeBase", 1)
+	add("Plain", 100)
+	add("ItemForTask", 10)
+	add("ItemForItem", 10)
+	add("ItemForTool", 10)
+	for _, d := range directions {
+		add("GateTo"+d.name, 8)
+	}
+
+	puzzles := make([]dta.PuzzleInfo, 0)
+	for i := 0; i < 15; i++ {
+		puzzles = append(puzzles, dta.PuzzleInfo{Id: len(puzzles), Type: "MainQuest", LockItemId: 100 + i, RewardItemId: 500 + i})
+	}
+	for i := 0; i < 40; i++ {
+		puzzles = append(puzzles, dta.PuzzleInfo{Id: len(puzzles), Type: "ItemForItem", LockItemId: 200 + i, RewardItemId: 300 + i})
+		puzzles = append(puzzles, dta.PuzzleInfo{Id: len(puzzles), Type: "ItemForTask", LockItemId: 400 + i})
+	}
+	return zones, puzzles
+}
+
+func TestGenerate(t *testing.T) {
+	zones, puzzles := synthData()
+	for _, size := range []string{"small", "medium", "large"} {
+		for _, seed := range []int64{1, 2, 42, 123456789} {
+			w := Sizes[size]
+			name := fmt.Sprintf("%s seed %d", size, seed)
+			opts := Options{Width: w, Height: w, PuzzleSteps: DefaultSteps(w, w), MainQuest: -1}
+			p, err := Generate(zones, puzzles, opts, &rng.New(seed).Worldgen)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			checkChain(t, name, p, zones, puzzles)
+		}
+	}
+}
+
+// Every step has to be somewhere the player can walk to, through the gates they've opened so far,
+// with whatever it needs handed out by a step before it
+func checkChain(t *testing.T, name string, p *Planet, zones []dta.ZoneInfo, puzzles []dta.PuzzleInfo) {
+	steps := p.Chain.Steps
+	if len(steps) == 0 {
+		t.Errorf("%s: no puzzle chain", name)
+		return
+	}
+	gates := make(map[cell]int)
+	for i, s := range steps {
+		if strings.HasPrefix(s.Type, "GateTo") {
+			gates[cell{s.X, s.Y}] = i
+		}
+	}
+
+	have := make(map[int]bool)
+	for i, s := range steps {
+		if p.Zones[s.X][s.Y] != s.ZoneId {
+			t.Errorf("%s: step %d (%s) isn't on the map", name, i, s.ToString())
+		}
+		if zones[s.ZoneId].Type != s.Type {
+			t.Errorf("%s: step %d (%s) is in a %s zone", name, i, s.ToString(), zones[s.ZoneId].Type)
+		}
+		if !canReach(p, cell{p.StartX, p.StartY}, cell{s.X, s.Y}, gates, i) {
+			t.Errorf("%s: can't get to step %d (%s)", name, i, s.ToString())
+		}
+		if strings.HasPrefix(s.Type, "GateTo") && s.Needs < 0 {
+			t.Errorf("%s: gate at step %d doesn't need anything", name, i)
+		}
+		if s.Needs >= 0 && !have[s.Needs] {
+			t.Errorf("%s: step %d (%s) needs an item nothing's handed out yet", name, i, s.ToString())
+		}
+		if i == 0 && s.Type != "ItemForTask" {
+			t.Errorf("%s: first step is %s, want ItemForTask", name, s.Type)
+		}
+		if s.Gives >= 0 {
+			have[s.Gives] = true
+		}
+	}
+
+	last := steps[len(steps)-1]
+	goal := puzzles[p.Chain.Goal]
+	if last.Type != "FinalDestination" {
+		t.Errorf("%s: last step is %s, want FinalDestination", name, last.Type)
+	}
+	if goal.Type != "MainQuest" || last.Puzzle != goal.Id {
+		t.Errorf("%s: FinalDestination has puzzle %d, want MainQuest %d", name, last.Puzzle, goal.Id)
+	}
+	if want := itemOrNone(goal.LockItemId); last.Needs != want {
+		t.Errorf("%s: FinalDestination needs %d, want the quest's item %d", name, last.Needs, want)
+	}
+	if want := itemOrNone(goal.RewardItemId); last.Gives != want {
+		t.Errorf("%s: FinalDestination gives %d, want the quest's reward %d", name, last.Gives, want)
+	}
+}
+
+// Walk from one zone to another, only going through the gates before step
+func canReach(p *Planet, from, to cell, gates map[cell]int, step int) bool {
+	seen := map[cell]bool{from: true}
+	queue := []cell{from}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if c == to {
+			return true
+		}
+		for _, d := range directions {
+			n := cell{c.x + d.dx, c.y + d.dy}
+			if n.x < 0 || n.y < 0 || n.x >= p.Width || n.y >= p.Height || seen[n] || p.Zones[n.x][n.y] < 0 {
+				continue
+			}
+			if g, ok := gates[n]; ok && g > step {
+				continue
+			}
+			seen[n] = true
+			queue = append(queue, n)
+		}
+	}
+	return false
+}
+
+// The same seed has to give the same planet, or -seed can't bring a world back
+func TestSameSeed(t *testing.T) {
+	zones, puzzles := synthData()
+	opts := Options{Width: 10, Height: 10, PuzzleSteps: 6, MainQuest: -1}
+	first, err := Generate(zones, puzzles, opts, &rng.New(42).Worldgen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate(zones, puzzles, opts, &rng.New(42).Worldgen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("seed 42 gave two different planets:\n%+v\n%+v", first, second)
+	}
+}